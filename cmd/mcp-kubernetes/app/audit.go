@@ -0,0 +1,113 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/audit"
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// newAuditCommand creates the command that inspects the audit store.
+func newAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the audit store",
+	}
+	cmd.AddCommand(newAuditQueryCommand())
+	return cmd
+}
+
+func newAuditQueryCommand() *cobra.Command {
+	opts := audit.NewOptions()
+	var (
+		q            audit.Query
+		since, until string
+		output       string
+	)
+	cmd := &cobra.Command{
+		Use:   "query",
+		Short: "Query the audit records of past tool calls, most recent first",
+		Example: `  # Show the calls of the last day in the team-a namespace
+  mcp-kubernetes-server audit query --audit.database audit.db --namespace team-a --since 24h`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The database may also come from the configuration file or the environment
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				return err
+			}
+			if err := viper.UnmarshalKey("audit", opts); err != nil {
+				return err
+			}
+			if opts.Database == "" {
+				return fmt.Errorf("--audit.database is required")
+			}
+			if err := utilerrors.NewAggregate(opts.Validate()); err != nil {
+				return err
+			}
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json")
+			}
+
+			var err error
+			now := time.Now()
+			if q.Since, err = audit.ParseTime(since, now); err != nil {
+				return fmt.Errorf("--since: %w", err)
+			}
+			if q.Until, err = audit.ParseTime(until, now); err != nil {
+				return fmt.Errorf("--until: %w", err)
+			}
+
+			store, err := audit.OpenReadOnlyStore(opts)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = store.Close() }()
+			records, err := store.Query(cmd.Context(), q)
+			if err != nil {
+				return err
+			}
+
+			if output == "json" {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				for _, r := range records {
+					if err := encoder.Encode(r); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			table := uitable.New()
+			table.AddRow("TIME", "SESSION", "USER", "TOOL", "NAMESPACE", "OUTCOME", "DURATION")
+			for _, r := range records {
+				var user, namespace string
+				if r.User != nil {
+					user = r.User.Username
+				}
+				if len(r.Targets) > 0 {
+					namespace = r.Targets[0].Namespace
+				}
+				table.AddRow(r.Timestamp.Format(time.RFC3339), r.SessionID, user, r.Tool, namespace, r.Outcome,
+					time.Duration(r.DurationMs*float64(time.Millisecond)).String())
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), table)
+			return err
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&opts.Driver, "audit.driver", opts.Driver, "SQL database `DRIVER` of the audit store, only sqlite is supported.")
+	fs.StringVar(&opts.Database, "audit.database", opts.Database, "Data source of the audit store, e.g. the SQLite database `FILE`.")
+	fs.StringVar(&q.Username, "user", "", "Only show the calls of this username.")
+	fs.StringVar(&q.Tool, "tool", "", "Only show the calls of this tool.")
+	fs.StringVar(&q.Namespace, "namespace", "", "Only show the calls targeting this namespace.")
+	fs.StringVar(&since, "since", "", "Only show the calls made at or after this RFC 3339 timestamp or duration before now (e.g. 24h).")
+	fs.StringVar(&until, "until", "", "Only show the calls made at or before this RFC 3339 timestamp or duration before now (e.g. 1h).")
+	fs.IntVar(&q.Limit, "limit", 100, "Maximum number of records to show.")
+	fs.StringVarP(&output, "output", "o", "table", "Output format, table or json.")
+	return cmd
+}
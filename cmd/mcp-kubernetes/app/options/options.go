@@ -1,9 +1,21 @@
 package options
 
 import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
 	mcpkubernetes "github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/audit"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/authn"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/mcp"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/policy"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/registry"
 	"github.com/fleezesd/mcp-kubernetes/pkg/app"
 	"github.com/fleezesd/mcp-kubernetes/pkg/log"
+	genericoptions "github.com/fleezesd/mcp-kubernetes/pkg/options"
 	"github.com/spf13/viper"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	cliflag "k8s.io/component-base/cli/flag"
@@ -12,25 +24,142 @@ import (
 var _ app.CliOptions = (*Options)(nil)
 
 type Options struct {
-	SSEPort    int          `json:"sse-port" mapstructure:"sse-port"`
-	SSEBaseURL string       `json:"sse-base-url" mapstructure:"sse-base-url"`
-	KubeConfig string       `json:"kubeconfig" mapstructure:"kubeconfig"`
-	Log        *log.Options `json:"log" mapstructure:"log"`
+	// Transports are derived from the port flags if empty.
+	Transports         []string      `json:"transport" mapstructure:"transport"`
+	SSEPort            int           `json:"sse-port" mapstructure:"sse-port"`
+	SSEBaseURL         string        `json:"sse-base-url" mapstructure:"sse-base-url"`
+	HTTPPort           int           `json:"http-port" mapstructure:"http-port"`
+	HTTPBasePath       string        `json:"http-base-path" mapstructure:"http-base-path"`
+	KubeConfig         string        `json:"kubeconfig" mapstructure:"kubeconfig"`
+	TokenPassthrough   bool          `json:"token-passthrough" mapstructure:"token-passthrough"`
+	RevealCredentials  bool          `json:"reveal-credentials" mapstructure:"reveal-credentials"`
+	ReadOnly           bool          `json:"read-only" mapstructure:"read-only"`
+	EnabledTools       []string      `json:"enabled-tools" mapstructure:"enabled-tools"`
+	DisabledTools      []string      `json:"disabled-tools" mapstructure:"disabled-tools"`
+	AllowedNamespaces  []string      `json:"allowed-namespaces" mapstructure:"allowed-namespaces"`
+	DeniedNamespaces   []string      `json:"denied-namespaces" mapstructure:"denied-namespaces"`
+	ConfirmDestructive bool          `json:"confirm-destructive" mapstructure:"confirm-destructive"`
+	ConfirmationTTL    time.Duration `json:"confirmation-ttl" mapstructure:"confirmation-ttl"`
+	SessionRate        float64       `json:"session-rate" mapstructure:"session-rate"`
+	SessionBurst       int           `json:"session-burst" mapstructure:"session-burst"`
+	ToolRate           float64       `json:"tool-rate" mapstructure:"tool-rate"`
+	ToolBurst          int           `json:"tool-burst" mapstructure:"tool-burst"`
+	MaxConcurrentCalls int           `json:"max-concurrent-calls" mapstructure:"max-concurrent-calls"`
+	KubeAPIQPS         float32       `json:"kube-api-qps" mapstructure:"kube-api-qps"`
+	KubeAPIBurst       int           `json:"kube-api-burst" mapstructure:"kube-api-burst"`
+	ToolTimeout        time.Duration `json:"tool-timeout" mapstructure:"tool-timeout"`
+	// ToolTimeouts maps tool names to their timeout, e.g. 30s.
+	ToolTimeouts   map[string]string `json:"tool-timeouts" mapstructure:"tool-timeouts"`
+	MaxToolTimeout time.Duration     `json:"max-tool-timeout" mapstructure:"max-tool-timeout"`
+	AllowedOrigins []string          `json:"allowed-origins" mapstructure:"allowed-origins"`
+	AllowedHosts   []string          `json:"allowed-hosts" mapstructure:"allowed-hosts"`
+	CORSMaxAge     time.Duration     `json:"cors-max-age" mapstructure:"cors-max-age"`
+	MaxSessions    int               `json:"max-sessions" mapstructure:"max-sessions"`
+	// SessionIdleTimeout only applies to the sessions of the network transports.
+	SessionIdleTimeout time.Duration `json:"session-idle-timeout" mapstructure:"session-idle-timeout"`
+	SessionAdmins      []string      `json:"session-admins" mapstructure:"session-admins"`
+	// Policies can only be set in the configuration file.
+	Policies []policy.Rule                 `json:"policies" mapstructure:"policies"`
+	Audit    *audit.Options                `json:"audit" mapstructure:"audit"`
+	TLS      *genericoptions.TLSOptions    `json:"tls" mapstructure:"tls"`
+	Authn    *authn.Options                `json:"authn" mapstructure:"authn"`
+	HTTP     *genericoptions.HTTPOptions   `json:"http" mapstructure:"http"`
+	GRPC     *genericoptions.GRPCOptions   `json:"grpc" mapstructure:"grpc"`
+	Registry *registry.Options             `json:"registry" mapstructure:"registry"`
+	Consul   *genericoptions.ConsulOptions `json:"consul" mapstructure:"consul"`
+	Log      *log.Options                  `json:"log" mapstructure:"log"`
 }
 
 func NewOptions() *Options {
+	httpOptions := genericoptions.NewHTTPOptions()
+	// The port flags select the loopback interface unless an address is set
+	httpOptions.Addr = ""
+	grpcOptions := genericoptions.NewGRPCOptions()
+	grpcOptions.Addr = "127.0.0.1:39090"
 	o := &Options{
-		Log: log.NewOptions(),
+		ConfirmationTTL: 2 * time.Minute,
+		SessionBurst:    10,
+		ToolBurst:       10,
+		ToolTimeout:     time.Minute,
+		MaxToolTimeout:  10 * time.Minute,
+		CORSMaxAge:      10 * time.Minute,
+		// Clients of the Streamable HTTP transport may go away without ending their session
+		SessionIdleTimeout: time.Hour,
+		Audit:              audit.NewOptions(),
+		TLS:                genericoptions.NewTLSOptions(),
+		Authn:              authn.NewOptions(),
+		HTTP:               httpOptions,
+		GRPC:               grpcOptions,
+		Registry:           registry.NewOptions(),
+		Consul:             genericoptions.NewConsulOptions(),
+		Log:                log.NewOptions(),
 	}
 	return o
 }
 
 func (o *Options) Flags() (fss cliflag.NamedFlagSets) {
 	o.Log.AddFlags(fss.FlagSet("logs"))
+	o.Audit.AddFlags(fss.FlagSet("audit"))
+	o.TLS.AddFlags(fss.FlagSet("tls"))
+	o.Authn.AddFlags(fss.FlagSet("authn"))
+	o.HTTP.AddFlags(fss.FlagSet("http"))
+	o.GRPC.AddFlags(fss.FlagSet("grpc"))
+	o.Registry.AddFlags(fss.FlagSet("registry"))
+	o.Consul.AddFlags(fss.FlagSet("registry"))
 	fs := fss.FlagSet("mcp-kubernetes-server")
+	fs.StringSliceVar(&o.Transports, "transport", o.Transports, "Transports to serve, any of stdio, sse, http and grpc (e.g. stdio,sse). "+
+		"Defaults to sse or http when their port is set, stdio otherwise.")
 	fs.IntVar(&o.SSEPort, "sse-port", 0, "Start a SSE server on the specified port")
 	fs.StringVar(&o.SSEBaseURL, "sse-base-url", "", "SSE public base URL to use when sending the endpoint message (e.g. https://example.com)")
+	fs.IntVar(&o.HTTPPort, "http-port", 0, "Start a Streamable HTTP server on the specified port, serving the /mcp endpoint")
+	fs.StringVar(&o.HTTPBasePath, "http-base-path", o.HTTPBasePath, "Path prefix of the SSE (/sse, /message) and Streamable HTTP (/mcp) "+
+		"endpoints (e.g. /mcp/k8s/), also used in the endpoint message along with --sse-base-url.")
 	fs.StringVar(&o.KubeConfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+	fs.BoolVar(&o.TokenPassthrough, "token-passthrough", false, "Authenticate each SSE or HTTP session against the Kubernetes API server "+
+		"with the bearer token of its Authorization header instead of the kubeconfig credentials.")
+	fs.BoolVar(&o.RevealCredentials, "reveal-credentials", false, "Return tokens, passwords and client keys verbatim from configuration_view "+
+		"instead of replacing them with REDACTED.")
+	fs.BoolVar(&o.ReadOnly, "read-only", false, "Only register tools that don't modify the cluster.")
+	fs.StringSliceVar(&o.EnabledTools, "enabled-tools", o.EnabledTools, "Glob patterns of the tools to register (e.g. namespace_*). "+
+		"All tools are registered if empty.")
+	fs.StringSliceVar(&o.DisabledTools, "disabled-tools", o.DisabledTools, "Glob patterns of the tools that must not be registered, "+
+		"takes precedence over --enabled-tools.")
+	fs.StringSliceVar(&o.AllowedNamespaces, "allowed-namespaces", o.AllowedNamespaces, "Glob patterns of the namespaces tools may reach (e.g. team-*). "+
+		"All namespaces are allowed if empty.")
+	fs.StringSliceVar(&o.DeniedNamespaces, "denied-namespaces", o.DeniedNamespaces, "Glob patterns of the namespaces tools must never reach "+
+		"(e.g. kube-*), takes precedence over --allowed-namespaces.")
+	fs.BoolVar(&o.ConfirmDestructive, "confirm-destructive", false, "Return a plan and a confirmation token on the first call of a destructive tool, "+
+		"and only execute it when called again with the same arguments and the token.")
+	fs.DurationVar(&o.ConfirmationTTL, "confirmation-ttl", o.ConfirmationTTL, "How long a confirmation token of a destructive tool remains valid.")
+	fs.Float64Var(&o.SessionRate, "session-rate", o.SessionRate, "Tool calls per second allowed for every MCP session, unlimited if 0.")
+	fs.IntVar(&o.SessionBurst, "session-burst", o.SessionBurst, "Tool calls an MCP session may make at once above --session-rate.")
+	fs.Float64Var(&o.ToolRate, "tool-rate", o.ToolRate, "Calls per second allowed for every tool across all sessions, unlimited if 0.")
+	fs.IntVar(&o.ToolBurst, "tool-burst", o.ToolBurst, "Calls a tool may receive at once above --tool-rate.")
+	fs.IntVar(&o.MaxConcurrentCalls, "max-concurrent-calls", o.MaxConcurrentCalls, "Tool calls allowed to run at the same time, "+
+		"unlimited if 0.")
+	fs.Float32Var(&o.KubeAPIQPS, "kube-api-qps", o.KubeAPIQPS, "Requests per second sent to the Kubernetes API server, "+
+		"the client-go default is used if 0.")
+	fs.IntVar(&o.KubeAPIBurst, "kube-api-burst", o.KubeAPIBurst, "Requests sent to the Kubernetes API server at once above --kube-api-qps, "+
+		"the client-go default is used if 0.")
+	fs.DurationVar(&o.ToolTimeout, "tool-timeout", o.ToolTimeout, "How long a tool call may run before it's aborted, unbounded if 0.")
+	fs.StringToStringVar(&o.ToolTimeouts, "tool-timeouts", o.ToolTimeouts, "Timeouts of specific tools overriding --tool-timeout "+
+		"(e.g. namespace_list=10s,auth_rules=30s).")
+	fs.DurationVar(&o.MaxToolTimeout, "max-tool-timeout", o.MaxToolTimeout, "Longest timeout a tool call may ask for "+
+		"with the timeout_seconds argument, unbounded if 0.")
+	fs.StringSliceVar(&o.AllowedOrigins, "allowed-origins", o.AllowedOrigins, "Glob patterns of the origins browsers may reach the SSE "+
+		"and HTTP transports from (e.g. https://*.intranet.example.com). Only loopback origins are allowed if empty.")
+	fs.StringSliceVar(&o.AllowedHosts, "allowed-hosts", o.AllowedHosts, "Glob patterns of the Host headers the SSE and HTTP transports "+
+		"accept (e.g. mcp.example.com). If empty, servers listening on the loopback interface only accept localhost, "+
+		"the loopback addresses and the host of --sse-base-url, other servers accept any host.")
+	fs.DurationVar(&o.CORSMaxAge, "cors-max-age", o.CORSMaxAge, "How long browsers may cache the answer to a CORS preflight request.")
+	fs.IntVar(&o.MaxSessions, "max-sessions", o.MaxSessions, "Sessions the sse, http and grpc transports may have open at the same time, "+
+		"unlimited if 0. Clients opening more are rejected.")
+	fs.DurationVar(&o.SessionIdleTimeout, "session-idle-timeout", o.SessionIdleTimeout, "How long a session of the sse, http or grpc "+
+		"transport may go without sending a request before it's terminated, forever if 0.")
+	fs.StringSliceVar(&o.SessionAdmins, "session-admins", o.SessionAdmins, "Glob patterns of the authenticated usernames allowed "+
+		"to list and terminate the sessions with the session_list and session_terminate tools, which are only registered if set. "+
+		"They can also query the audit records of every user with audit_query, other users only get their own. "+
+		"The stdio client is always allowed to.")
 	return fss
 }
 
@@ -38,23 +167,203 @@ func (o *Options) Complete() error {
 	if err := viper.Unmarshal(&o); err != nil {
 		return err
 	}
+	// stdout carries the messages of the stdio transport, logs must not end up there
+	if slices.Contains(o.transports(), mcp.TransportStdio) && slices.Contains(o.Log.OutputPaths, "stdout") {
+		o.Log.OutputPaths = slices.Clone(o.Log.OutputPaths)
+		for i, outputPath := range o.Log.OutputPaths {
+			if outputPath == "stdout" {
+				o.Log.OutputPaths[i] = "stderr"
+			}
+		}
+		viper.Set("log.output-paths", o.Log.OutputPaths)
+	}
 	return nil
 }
 
 func (o *Options) Validate() error {
 	errs := []error{}
 
+	transports := o.transports()
+	for _, transport := range transports {
+		if !slices.Contains([]string{mcp.TransportStdio, mcp.TransportSSE, mcp.TransportHTTP, mcp.TransportGRPC}, transport) {
+			errs = append(errs, fmt.Errorf("invalid transport %q, must be one of stdio, sse, http and grpc", transport))
+		}
+	}
+	if slices.Contains(transports, mcp.TransportSSE) && o.SSEPort <= 0 && o.HTTP.Addr == "" {
+		errs = append(errs, fmt.Errorf("the sse transport requires --sse-port or --http.addr to be set"))
+	}
+	if slices.Contains(transports, mcp.TransportHTTP) && o.HTTPPort <= 0 && o.HTTP.Addr == "" {
+		errs = append(errs, fmt.Errorf("the http transport requires --http-port or --http.addr to be set"))
+	}
+	if slices.Contains(transports, mcp.TransportGRPC) {
+		errs = append(errs, o.GRPC.Validate()...)
+	}
+	network := slices.Contains(transports, mcp.TransportSSE) || slices.Contains(transports, mcp.TransportHTTP) ||
+		slices.Contains(transports, mcp.TransportGRPC)
+
+	if o.TokenPassthrough && !network {
+		errs = append(errs, fmt.Errorf("--token-passthrough requires the sse, http or grpc transport"))
+	}
+
+	for _, pattern := range slices.Concat(o.EnabledTools, o.DisabledTools) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid tool pattern %q: %w", pattern, err))
+		}
+	}
+
+	if o.ConfirmDestructive && o.ConfirmationTTL <= 0 {
+		errs = append(errs, fmt.Errorf("--confirmation-ttl must be greater than 0"))
+	}
+
+	if o.SessionRate < 0 || o.ToolRate < 0 || o.KubeAPIQPS < 0 {
+		errs = append(errs, fmt.Errorf("--session-rate, --tool-rate and --kube-api-qps cannot be negative"))
+	}
+	if o.SessionBurst < 0 || o.ToolBurst < 0 || o.KubeAPIBurst < 0 || o.MaxConcurrentCalls < 0 {
+		errs = append(errs, fmt.Errorf("--session-burst, --tool-burst, --kube-api-burst and --max-concurrent-calls cannot be negative"))
+	}
+
+	if o.ToolTimeout < 0 || o.MaxToolTimeout < 0 {
+		errs = append(errs, fmt.Errorf("--tool-timeout and --max-tool-timeout cannot be negative"))
+	}
+	if _, err := o.toolTimeouts(); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, pattern := range slices.Concat(o.AllowedOrigins, o.AllowedHosts) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid origin or host pattern %q: %w", pattern, err))
+		}
+	}
+	if o.HTTPBasePath != "" {
+		if !slices.Contains(transports, mcp.TransportSSE) && !slices.Contains(transports, mcp.TransportHTTP) {
+			errs = append(errs, fmt.Errorf("--http-base-path requires the sse or http transport"))
+		}
+		if strings.ContainsAny(o.HTTPBasePath, "?#*{} ") {
+			errs = append(errs, fmt.Errorf("invalid --http-base-path %q, must be a plain URL path", o.HTTPBasePath))
+		}
+	}
+	if o.CORSMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("--cors-max-age cannot be negative"))
+	}
+
+	if o.MaxSessions < 0 || o.SessionIdleTimeout < 0 {
+		errs = append(errs, fmt.Errorf("--max-sessions and --session-idle-timeout cannot be negative"))
+	}
+	for _, pattern := range o.SessionAdmins {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid session admin pattern %q: %w", pattern, err))
+		}
+	}
+
+	for _, pattern := range slices.Concat(o.AllowedNamespaces, o.DeniedNamespaces) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid namespace pattern %q: %w", pattern, err))
+		}
+	}
+
+	if _, err := policy.New(o.Policies); err != nil {
+		errs = append(errs, err)
+	}
+
+	if o.TLS.UseTLS {
+		if !network {
+			errs = append(errs, fmt.Errorf("--tls.use-tls requires the sse, http or grpc transport"))
+		}
+		if o.TLS.Cert == "" || o.TLS.Key == "" {
+			errs = append(errs, fmt.Errorf("--tls.use-tls requires --tls.cert and --tls.key to be set"))
+		}
+	}
+
+	if o.Authn.Enabled() && !network {
+		errs = append(errs, fmt.Errorf("--authn.* requires the sse, http or grpc transport"))
+	}
+
+	if o.HTTP.Addr != "" {
+		if !slices.Contains(transports, mcp.TransportSSE) && !slices.Contains(transports, mcp.TransportHTTP) {
+			errs = append(errs, fmt.Errorf("--http.addr requires the sse or http transport"))
+		}
+		errs = append(errs, o.HTTP.Validate()...)
+	} else if o.HTTP.ReadTimeout < 0 || o.HTTP.WriteTimeout < 0 || o.HTTP.IdleTimeout < 0 {
+		errs = append(errs, fmt.Errorf("http server timeouts cannot be negative"))
+	}
+
+	if o.Registry.Enabled {
+		if !slices.Contains(transports, mcp.TransportSSE) && !slices.Contains(transports, mcp.TransportHTTP) {
+			errs = append(errs, fmt.Errorf("--registry.enabled requires the sse or http transport"))
+		}
+		if o.HTTP.Addr != "" && o.HTTP.Network == "unix" {
+			errs = append(errs, fmt.Errorf("--registry.enabled cannot register unix socket listeners"))
+		}
+		errs = append(errs, o.Consul.Validate()...)
+	}
+
+	errs = append(errs, o.Audit.Validate()...)
+	errs = append(errs, o.Registry.Validate()...)
+	errs = append(errs, o.Authn.Validate()...)
+	errs = append(errs, o.TLS.Validate()...)
 	errs = append(errs, o.Log.Validate()...)
 	return utilerrors.NewAggregate(errs)
 }
 
 func (o *Options) ApplyTo(c *mcpkubernetes.Config) error {
+	c.Transports = o.transports()
 	c.SSEPort = o.SSEPort
 	c.SSEBaseURL = o.SSEBaseURL
+	c.HTTPPort = o.HTTPPort
+	c.HTTPBasePath = o.httpBasePath()
 	c.KubeConfig = o.KubeConfig
+	c.TokenPassthrough = o.TokenPassthrough
+	c.RevealCredentials = o.RevealCredentials
+	c.ReadOnly = o.ReadOnly
+	c.EnabledTools = o.EnabledTools
+	c.DisabledTools = o.DisabledTools
+	c.AllowedNamespaces = o.AllowedNamespaces
+	c.DeniedNamespaces = o.DeniedNamespaces
+	c.ConfirmDestructive = o.ConfirmDestructive
+	c.ConfirmationTTL = o.ConfirmationTTL
+	c.Policies = o.Policies
+	c.Audit = o.Audit
+	c.TLS = o.TLS
+	c.Authn = o.Authn
+	c.HTTP = o.HTTP
+	c.GRPC = o.GRPC
+	c.AllowedOrigins = o.AllowedOrigins
+	c.AllowedHosts = o.AllowedHosts
+	c.CORSMaxAge = o.CORSMaxAge
+	c.MaxSessions = o.MaxSessions
+	c.SessionIdleTimeout = o.SessionIdleTimeout
+	c.SessionAdmins = o.SessionAdmins
+	c.Registry = o.Registry
+	c.Consul = o.Consul
+	c.SessionRate = o.SessionRate
+	c.SessionBurst = o.SessionBurst
+	c.ToolRate = o.ToolRate
+	c.ToolBurst = o.ToolBurst
+	c.MaxConcurrentCalls = o.MaxConcurrentCalls
+	c.KubeAPIQPS = o.KubeAPIQPS
+	c.KubeAPIBurst = o.KubeAPIBurst
+	c.ToolTimeout = o.ToolTimeout
+	c.MaxToolTimeout = o.MaxToolTimeout
+	toolTimeouts, err := o.toolTimeouts()
+	if err != nil {
+		return err
+	}
+	c.ToolTimeouts = toolTimeouts
 	return nil
 }
 
+func (o *Options) toolTimeouts() (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(o.ToolTimeouts))
+	for tool, value := range o.ToolTimeouts {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid timeout %q of tool %s in --tool-timeouts", value, tool)
+		}
+		timeouts[tool] = timeout
+	}
+	return timeouts, nil
+}
+
 // Config return xnightwatch config object.
 func (o *Options) Config() (*mcpkubernetes.Config, error) {
 	c := &mcpkubernetes.Config{}
@@ -64,3 +373,30 @@ func (o *Options) Config() (*mcpkubernetes.Config, error) {
 	}
 	return c, nil
 }
+
+// httpBasePath returns the base path with a leading and without a trailing slash, empty for the root.
+func (o *Options) httpBasePath() string {
+	basePath := strings.Trim(o.HTTPBasePath, "/")
+	if basePath == "" {
+		return ""
+	}
+	return "/" + basePath
+}
+
+// transports returns the configured transports, or the ones selected by the port flags.
+func (o *Options) transports() []string {
+	if len(o.Transports) > 0 {
+		return o.Transports
+	}
+	var transports []string
+	if o.SSEPort > 0 {
+		transports = append(transports, mcp.TransportSSE)
+	}
+	if o.HTTPPort > 0 {
+		transports = append(transports, mcp.TransportHTTP)
+	}
+	if len(transports) == 0 {
+		transports = append(transports, mcp.TransportStdio)
+	}
+	return transports
+}
@@ -3,7 +3,10 @@ package app
 import (
 	"github.com/fleezesd/mcp-kubernetes/cmd/mcp-kubernetes/app/options"
 	mcpkubernetes "github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/policy"
 	"github.com/fleezesd/mcp-kubernetes/pkg/app"
+	"github.com/fleezesd/mcp-kubernetes/pkg/log"
+	"github.com/spf13/viper"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 )
 
@@ -12,14 +15,65 @@ const commandDesc = `
 
   Usage:
     kubernetes-mcp-server [flags]
+    kubernetes-mcp-server audit query [flags]
 
   Available Commands:
     -h, --help        Display help information
     --version         Display version information
+    audit query       Query the audit store (e.g. --user, --tool, --namespace, --since 24h)
     
   Server Options:
+    --transport       Transports to serve, any of stdio, sse, http and grpc (e.g. stdio,sse)
+                      Defaults to the transports whose port is set, stdio otherwise
     --sse-port        Port number for SSE server (e.g. 8080, 8443)
     --sse-base-url    Base URL for HTTPS host (e.g. https://example.com:8443)
+    --http-port       Port number for Streamable HTTP server, served on /mcp (e.g. 8080)
+                      The SSE and HTTP servers listen on 127.0.0.1 unless --http.addr is set
+    --http-base-path  Path prefix of the SSE and HTTP endpoints (e.g. /mcp/k8s/ serves /mcp/k8s/sse)
+    --http.addr       Bind address of the SSE or HTTP server (e.g. 0.0.0.0:8080), or the socket path
+                      with --http.network unix
+    --http.read-timeout, --http.write-timeout, --http.idle-timeout
+                      Timeouts of the SSE or HTTP server connections
+    --grpc.addr       Bind address of the gRPC server (default 127.0.0.1:39090)
+    --registry.enabled
+                      Register the SSE and HTTP listeners in the Consul agent of --consul.addr
+    --allowed-origins Glob patterns of the origins browsers may reach the SSE or HTTP server from
+                      (e.g. https://*.intranet.example.com), loopback origins if empty
+    --allowed-hosts   Glob patterns of the Host headers the SSE or HTTP server accepts (e.g. mcp.example.com),
+                      loopback host names if empty and the server listens on 127.0.0.1
+    --tls.use-tls     Serve the SSE, HTTP or gRPC transport over TLS with --tls.cert and --tls.key
+    --tls.client-ca   CA verifying client certificates, enables mutual TLS
+    --authn.token-file
+                      CSV file of static bearer tokens the SSE or HTTP clients must present
+    --authn.jwt.issuer, --authn.jwt.audience, --authn.jwt.jwks-url
+                      Require SSE or HTTP clients to present a JSON Web Token of the issuer
+    --token-passthrough
+                      Use the Authorization bearer token of each SSE or HTTP session for Kubernetes requests
+    --reveal-credentials
+                      Do not redact credentials returned by configuration_view
+    --read-only       Only expose tools that don't modify the cluster
+    --enabled-tools   Glob patterns of the tools to expose (e.g. namespace_*,auth_*)
+    --disabled-tools  Glob patterns of the tools to hide, takes precedence over --enabled-tools
+    --allowed-namespaces
+                      Glob patterns of the namespaces tools may reach (e.g. team-*)
+    --denied-namespaces
+                      Glob patterns of the namespaces tools must never reach (e.g. kube-*)
+    --confirm-destructive
+                      Require destructive tool calls to be confirmed with the token of their plan
+    --session-rate    Tool calls per second allowed for every session (e.g. 5)
+    --tool-rate       Calls per second allowed for every tool across all sessions
+    --max-concurrent-calls
+                      Tool calls allowed to run at the same time
+    --max-sessions    Sessions the SSE, HTTP and gRPC servers may have open at the same time
+    --session-idle-timeout
+                      How long a network session may stay idle before it's terminated (default 1h)
+    --session-admins  Glob patterns of the authenticated users allowed to list and terminate sessions,
+                      and to query the audit records of every user
+    --kube-api-qps    Requests per second sent to the Kubernetes API server
+    --tool-timeout    How long a tool call may run before it's aborted (e.g. 1m)
+    --tool-timeouts   Timeouts of specific tools (e.g. namespace_list=10s)
+    --audit.path      File every tool call is appended to as hash chained JSON lines
+    --audit.database  SQLite database every tool call is stored in, enables the audit_query tool
 
   Examples:
     # Start STDIO server
@@ -29,7 +83,42 @@ const commandDesc = `
     kubernetes-mcp-server --sse-port 8080
 
     # Start SSE server on port 8443 with HTTPS
-    kubernetes-mcp-server --sse-port 8443 --sse-base-url https://example.com:8443
+    kubernetes-mcp-server --sse-port 8443 --sse-base-url https://example.com:8443 \
+      --tls.use-tls --tls.cert server.crt --tls.key server.key
+
+    # Serve SSE and Streamable HTTP on port 8080 behind a proxy forwarding https://example.com/mcp/k8s/
+    kubernetes-mcp-server --sse-port 8080 --http-port 8080 --sse-base-url https://example.com --http-base-path /mcp/k8s/
+
+    # Start Streamable HTTP server on port 8080
+    kubernetes-mcp-server --http-port 8080
+
+    # Serve STDIO, SSE on port 8080 and Streamable HTTP on port 8081 at the same time
+    kubernetes-mcp-server --transport stdio,sse,http --sse-port 8080 --http-port 8081
+
+    # Start Streamable HTTP server on port 8080 discoverable through the local Consul agent
+    kubernetes-mcp-server --http-port 8080 --http.addr 0.0.0.0:8080 --registry.enabled --consul.addr 127.0.0.1:8500
+
+    # Serve the tools over gRPC to other services, see pkg/api/mcpkubernetes/v1/tool.proto
+    kubernetes-mcp-server --transport grpc --grpc.addr 0.0.0.0:39090
+
+    # Start Streamable HTTP server on a unix socket only the current user can connect to
+    kubernetes-mcp-server --http-port 8080 --http.network unix --http.addr /run/mcp-kubernetes.sock
+
+    # Start STDIO server that cannot modify the cluster
+    kubernetes-mcp-server --read-only
+
+    # Start STDIO server exposing every tool but the configuration ones
+    kubernetes-mcp-server --disabled-tools 'configuration_*'
+
+    # Start Streamable HTTP server on port 8080 accepting JSON Web Tokens of an issuer
+    kubernetes-mcp-server --http-port 8080 --authn.jwt.issuer https://issuer.example.com \
+      --authn.jwt.audience mcp-kubernetes --authn.jwt.jwks-url https://issuer.example.com/jwks
+
+    # Start SSE server on port 8080 authenticating every session with its own bearer token
+    kubernetes-mcp-server --sse-port 8080 --token-passthrough
+
+    # Show the tool calls of the last day stored in the audit database
+    kubernetes-mcp-server audit query --audit.database audit.db --since 24h
 `
 
 func NewApp() *app.App {
@@ -39,6 +128,8 @@ func NewApp() *app.App {
 		app.WithDescription(commandDesc),
 		app.WithOptions(opts),
 		app.WithRunFunc(run(opts)),
+		app.WithWatchConfig(),
+		app.WithCommands(newAuditCommand()),
 	)
 	return application
 }
@@ -58,7 +149,18 @@ func Run(c *mcpkubernetes.Config, stopCh <-chan struct{}) error {
 	if err != nil {
 		return err
 	}
+	app.OnConfigChange(func() {
+		var rules []policy.Rule
+		if err := viper.UnmarshalKey("policies", &rules); err != nil {
+			log.Errorw(err, "Failed to read policies from configuration file")
+			return
+		}
+		if err := mcpServer.SetPolicies(rules); err != nil {
+			log.Errorw(err, "Failed to reload policies, keeping the previous ones")
+			return
+		}
+		log.Infow("Reloaded policies", "count", len(rules))
+	})
 
-	mcpServer.Run(c.SSEBaseURL, c.SSEPort, stopCh)
-	return nil
+	return mcpServer.Run(c.Transports, c.SSEBaseURL, c.SSEPort, c.HTTPPort, stopCh)
 }
@@ -0,0 +1,222 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Outcomes of a tool call.
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// Record describes a single tool call.
+type Record struct {
+	Timestamp time.Time `json:"timestamp"`
+	SessionID string    `json:"sessionId,omitempty"`
+	Client    *Client   `json:"client,omitempty"`
+	User      *User     `json:"user,omitempty"`
+	// Principal is who authenticated to the SSE or HTTP transport, if authentication is enabled.
+	Principal  *User          `json:"principal,omitempty"`
+	Tool       string         `json:"tool"`
+	Arguments  map[string]any `json:"arguments,omitempty"`
+	Targets    []Target       `json:"targets,omitempty"`
+	Outcome    string         `json:"outcome"`
+	Error      string         `json:"error,omitempty"`
+	DurationMs float64        `json:"durationMs"`
+	// PrevHash is the hash of the previous record, chaining records so that
+	// removing or altering one of them is detectable.
+	PrevHash string `json:"prevHash"`
+	// Hash is the SHA-256 of PrevHash followed by the record marshaled with an empty Hash.
+	Hash string `json:"hash"`
+}
+
+// Client identifies the MCP client of a session.
+type Client struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// User is who the tool call was performed on behalf of.
+type User struct {
+	Username string   `json:"username,omitempty"`
+	UID      string   `json:"uid,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// Target is an object a tool call acts on.
+type Target struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+}
+
+// Logger appends hash chained records as JSON lines to a sink and persists them
+// in the audit store. The sink and the store chain their records separately, each
+// continues its own chain across restarts.
+type Logger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	store  *Store
+	// sinkPrevHash and storePrevHash are the hashes of the last record of the sink and the store.
+	sinkPrevHash  string
+	storePrevHash string
+}
+
+// New opens the sink and the store configured by opts. It returns nil if both are disabled.
+func New(opts *Options) (*Logger, error) {
+	if opts == nil || (opts.Path == "" && opts.Database == "") {
+		return nil, nil
+	}
+
+	l := &Logger{}
+	store, err := NewStore(opts)
+	if err != nil {
+		return nil, err
+	}
+	if store != nil {
+		l.store = store
+		if l.storePrevHash, err = store.lastHash(context.Background()); err != nil {
+			_ = store.Close()
+			return nil, fmt.Errorf("failed to read audit database: %w", err)
+		}
+	}
+
+	switch opts.Path {
+	case "":
+	case "stderr":
+		l.w = os.Stderr
+	default:
+		l.sinkPrevHash, err = lastHash(opts.Path)
+		if err != nil {
+			_ = l.store.Close()
+			return nil, fmt.Errorf("failed to read audit log %s: %w", opts.Path, err)
+		}
+		f, err := os.OpenFile(opts.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			_ = l.store.Close()
+			return nil, fmt.Errorf("failed to open audit log %s: %w", opts.Path, err)
+		}
+		l.w, l.closer = f, f
+	}
+	return l, nil
+}
+
+// Store returns the audit store, nil if it is disabled.
+func (l *Logger) Store() *Store {
+	if l == nil {
+		return nil
+	}
+	return l.store
+}
+
+// lastHash returns the hash of the last record of an existing audit log so the
+// chain continues across restarts.
+func lastHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var last []byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			last = append(last[:0], line...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if last == nil {
+		return "", nil
+	}
+	var record Record
+	if err := json.Unmarshal(last, &record); err != nil {
+		return "", fmt.Errorf("last record is not valid: %w", err)
+	}
+	return record.Hash, nil
+}
+
+// Log chains r to the previous record of the sink and of the store, and writes it
+// to both. r ends up chained as in the sink if there is one.
+func (l *Logger) Log(r *Record) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var errs []error
+	if l.store != nil {
+		stored := *r
+		err := chain(&stored, l.storePrevHash)
+		if err == nil {
+			err = l.store.Create(context.Background(), &stored)
+		}
+		if err == nil {
+			l.storePrevHash = stored.Hash
+		}
+		errs = append(errs, err)
+		*r = stored
+	}
+	if l.w != nil {
+		err := chain(r, l.sinkPrevHash)
+		if err == nil {
+			var line []byte
+			if line, err = json.Marshal(r); err == nil {
+				_, err = l.w.Write(append(line, '\n'))
+			}
+		}
+		if err == nil {
+			l.sinkPrevHash = r.Hash
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// chain sets the PrevHash of r to prevHash and computes its Hash.
+func chain(r *Record, prevHash string) error {
+	r.PrevHash = prevHash
+	r.Hash = ""
+	unhashed, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(append([]byte(r.PrevHash), unhashed...))
+	r.Hash = hex.EncodeToString(sum[:])
+	return nil
+}
+
+// Close closes the sink and the store.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	var errs []error
+	if l.closer != nil {
+		errs = append(errs, l.closer.Close())
+	}
+	errs = append(errs, l.store.Close())
+	return errors.Join(errs...)
+}
@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLoggerRestart(t *testing.T) {
+	tests := []struct {
+		name string
+		// runs are the sinks enabled each time the logger is started
+		runs []struct{ file, database bool }
+		// wantFile and wantDatabase are the number of records expected in each sink
+		wantFile     int
+		wantDatabase int
+	}{
+		{
+			name:         "file and database",
+			runs:         []struct{ file, database bool }{{true, true}, {true, true}, {true, true}},
+			wantFile:     6,
+			wantDatabase: 6,
+		},
+		{
+			name:         "database before file",
+			runs:         []struct{ file, database bool }{{false, true}, {true, true}},
+			wantFile:     2,
+			wantDatabase: 4,
+		},
+		{
+			name:         "file before database",
+			runs:         []struct{ file, database bool }{{true, false}, {true, true}, {true, true}},
+			wantFile:     6,
+			wantDatabase: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path, database := filepath.Join(dir, "audit.jsonl"), filepath.Join(dir, "audit.db")
+			for i, run := range tt.runs {
+				opts := NewOptions()
+				if run.file {
+					opts.Path = path
+				}
+				if run.database {
+					opts.Database = database
+				}
+				l, err := New(opts)
+				if err != nil {
+					t.Fatalf("run %d: New() error = %v", i, err)
+				}
+				for j := range 2 {
+					r := &Record{
+						Timestamp:  time.Date(2025, 1, 2, 3, 4, 5, 123456789, time.UTC).Add(time.Duration(i*2+j) * time.Second),
+						SessionID:  "session",
+						Client:     &Client{Name: "client", Version: "1.0"},
+						User:       &User{Username: "alice", Groups: []string{"admins"}},
+						Tool:       "namespace_set_default",
+						Arguments:  map[string]any{"namespace": "shop", "replicas": 3.0},
+						Targets:    []Target{{APIVersion: "v1", Kind: "Namespace", Name: "shop"}},
+						Outcome:    OutcomeSuccess,
+						DurationMs: 1.5,
+					}
+					if err := l.Log(r); err != nil {
+						t.Fatalf("run %d: Log() error = %v", i, err)
+					}
+				}
+				if err := l.Close(); err != nil {
+					t.Fatalf("run %d: Close() error = %v", i, err)
+				}
+			}
+
+			fileRecords := readRecords(t, path)
+			if len(fileRecords) != tt.wantFile {
+				t.Errorf("file has %d records, want %d", len(fileRecords), tt.wantFile)
+			}
+			verifyChain(t, "file", fileRecords)
+
+			store, err := OpenReadOnlyStore(&Options{Driver: DriverSQLite, Database: database})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+			databaseRecords, err := store.Query(context.Background(), Query{Limit: 1000})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Query returns the most recent records first
+			slices.Reverse(databaseRecords)
+			if len(databaseRecords) != tt.wantDatabase {
+				t.Errorf("database has %d records, want %d", len(databaseRecords), tt.wantDatabase)
+			}
+			verifyChain(t, "database", databaseRecords)
+		})
+	}
+}
+
+// readRecords returns the records of a JSON lines audit log.
+func readRecords(t *testing.T, path string) []*Record {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []*Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		r := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+// verifyChain checks that every record is chained to the previous one and hashed as Record.Hash documents.
+func verifyChain(t *testing.T, sink string, records []*Record) {
+	t.Helper()
+	prevHash := ""
+	for i, r := range records {
+		if r.PrevHash != prevHash {
+			t.Errorf("%s record %d: prevHash = %q, want %q", sink, i, r.PrevHash, prevHash)
+		}
+		unhashed := *r
+		unhashed.Hash = ""
+		data, err := json.Marshal(&unhashed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(append([]byte(r.PrevHash), data...))
+		if want := hex.EncodeToString(sum[:]); r.Hash != want {
+			t.Errorf("%s record %d: hash = %q, want %q", sink, i, r.Hash, want)
+		}
+		prevHash = r.Hash
+	}
+}
@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+type Options struct {
+	// Path is the file audit records are appended to as JSON lines, or stderr.
+	// Auditing to a file is disabled if empty.
+	Path string `json:"path,omitempty" mapstructure:"path"`
+	// Driver is the SQL database driver of the audit store.
+	Driver string `json:"driver,omitempty" mapstructure:"driver"`
+	// Database is the data source of the audit store, e.g. the SQLite database file.
+	// The audit store is disabled if empty.
+	Database string `json:"database,omitempty" mapstructure:"database"`
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Driver: DriverSQLite,
+	}
+}
+
+func (o *Options) Validate() []error {
+	errs := []error{}
+	if o.Path == "stdout" || o.Path == "-" {
+		errs = append(errs, fmt.Errorf("--audit.path cannot be stdout, it is used by the stdio transport"))
+	}
+	if o.Database != "" && o.Driver != DriverSQLite {
+		errs = append(errs, fmt.Errorf("--audit.driver must be %q", DriverSQLite))
+	}
+	return errs
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Path, "audit.path", o.Path, "Append a JSON line audit record of every tool call to `FILE`, or to stderr. "+
+		"Auditing to a file is disabled if empty.")
+	fs.StringVar(&o.Driver, "audit.driver", o.Driver, "SQL database `DRIVER` of the queryable audit store, only sqlite is supported.")
+	fs.StringVar(&o.Database, "audit.database", o.Database, "Data source of the queryable audit store, e.g. the SQLite database `FILE`. "+
+		"The audit store is disabled if empty.")
+}
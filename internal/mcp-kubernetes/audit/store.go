@@ -0,0 +1,263 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fleezesd/mcp-kubernetes/pkg/log"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// DriverSQLite stores audit records in a SQLite database file.
+const DriverSQLite = "sqlite"
+
+// defaultQueryLimit is the number of records returned by a query without a limit.
+const defaultQueryLimit = 100
+
+// entry is how a Record is persisted in the audit store.
+type entry struct {
+	ID            uint      `gorm:"primaryKey"`
+	Timestamp     time.Time `gorm:"index"`
+	SessionID     string    `gorm:"index"`
+	ClientName    string
+	ClientVersion string
+	Username      string `gorm:"index"`
+	UID           string
+	Groups        string
+	// PrincipalUsername is indexed to query by, Principal holds the whole principal as JSON.
+	PrincipalUsername string `gorm:"index"`
+	Principal         string
+	Tool              string `gorm:"index"`
+	Namespace         string `gorm:"index"`
+	Arguments         string
+	Targets           string
+	Outcome           string
+	Error             string
+	DurationMs        float64
+	PrevHash          string
+	Hash              string
+}
+
+func (entry) TableName() string {
+	return "audit_records"
+}
+
+// Query filters the records returned by Store.Query. Zero values don't filter.
+type Query struct {
+	Username  string
+	Tool      string
+	Namespace string
+	Since     time.Time
+	Until     time.Time
+	// Limit is the maximum number of records to return, most recent first.
+	Limit int
+}
+
+// Store persists audit records in a SQL database.
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore opens the database configured by opts and migrates its schema.
+// It returns nil if no database is configured.
+func NewStore(opts *Options) (*Store, error) {
+	if opts == nil || opts.Database == "" {
+		return nil, nil
+	}
+
+	db, err := open(opts, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&entry{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate audit database: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// OpenReadOnlyStore opens the existing database configured by opts to query it.
+// Unlike NewStore, it neither creates the database nor migrates its schema.
+func OpenReadOnlyStore(opts *Options) (*Store, error) {
+	if opts == nil || opts.Database == "" {
+		return nil, fmt.Errorf("no audit database configured")
+	}
+	db, err := open(opts, true)
+	if err != nil {
+		return nil, err
+	}
+	if !db.Migrator().HasTable(&entry{}) {
+		store := &Store{db: db}
+		_ = store.Close()
+		return nil, fmt.Errorf("audit database %s has no audit records table", opts.Database)
+	}
+	return &Store{db: db}, nil
+}
+
+func open(opts *Options, readOnly bool) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch opts.Driver {
+	case DriverSQLite, "":
+		dsn := opts.Database
+		if readOnly {
+			// SQLite creates missing database files, even in read-only mode
+			if _, err := os.Stat(opts.Database); err != nil {
+				return nil, fmt.Errorf("failed to open audit database: %w", err)
+			}
+			dsn = "file:" + opts.Database + "?mode=ro"
+		}
+		dialector = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported audit database driver %q", opts.Driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: log.Default().LogMode(gormlogger.Warn)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit database: %w", err)
+	}
+	return db, nil
+}
+
+// Create persists r.
+func (s *Store) Create(ctx context.Context, r *Record) error {
+	e := &entry{
+		Timestamp:  r.Timestamp,
+		SessionID:  r.SessionID,
+		Tool:       r.Tool,
+		Outcome:    r.Outcome,
+		Error:      r.Error,
+		DurationMs: r.DurationMs,
+		PrevHash:   r.PrevHash,
+		Hash:       r.Hash,
+	}
+	if r.Client != nil {
+		e.ClientName, e.ClientVersion = r.Client.Name, r.Client.Version
+	}
+	if r.User != nil {
+		e.Username, e.UID = r.User.Username, r.User.UID
+		e.Groups = mustJSON(r.User.Groups)
+	}
+	if r.Principal != nil {
+		e.PrincipalUsername, e.Principal = r.Principal.Username, mustJSON(r.Principal)
+	}
+	if len(r.Targets) > 0 {
+		e.Namespace = r.Targets[0].Namespace
+		e.Targets = mustJSON(r.Targets)
+	}
+	if len(r.Arguments) > 0 {
+		e.Arguments = mustJSON(r.Arguments)
+	}
+	return s.db.WithContext(ctx).Create(e).Error
+}
+
+// Query returns the records matching q, most recent first.
+func (s *Store) Query(ctx context.Context, q Query) ([]*Record, error) {
+	tx := s.db.WithContext(ctx).Model(&entry{})
+	if q.Username != "" {
+		tx = tx.Where("username = ? OR principal_username = ?", q.Username, q.Username)
+	}
+	if q.Tool != "" {
+		tx = tx.Where("tool = ?", q.Tool)
+	}
+	if q.Namespace != "" {
+		tx = tx.Where("namespace = ?", q.Namespace)
+	}
+	if !q.Since.IsZero() {
+		tx = tx.Where("timestamp >= ?", q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		tx = tx.Where("timestamp <= ?", q.Until.UTC())
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+
+	var entries []entry
+	if err := tx.Order("id DESC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	records := make([]*Record, 0, len(entries))
+	for _, e := range entries {
+		r := &Record{
+			Timestamp:  e.Timestamp,
+			SessionID:  e.SessionID,
+			Tool:       e.Tool,
+			Outcome:    e.Outcome,
+			Error:      e.Error,
+			DurationMs: e.DurationMs,
+			PrevHash:   e.PrevHash,
+			Hash:       e.Hash,
+		}
+		if e.ClientName != "" || e.ClientVersion != "" {
+			r.Client = &Client{Name: e.ClientName, Version: e.ClientVersion}
+		}
+		if e.Username != "" || e.UID != "" {
+			r.User = &User{Username: e.Username, UID: e.UID}
+			_ = json.Unmarshal([]byte(e.Groups), &r.User.Groups)
+		}
+		if e.Principal != "" {
+			_ = json.Unmarshal([]byte(e.Principal), &r.Principal)
+		}
+		if e.Targets != "" {
+			_ = json.Unmarshal([]byte(e.Targets), &r.Targets)
+		}
+		if e.Arguments != "" {
+			_ = json.Unmarshal([]byte(e.Arguments), &r.Arguments)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// lastHash returns the hash of the most recent record.
+func (s *Store) lastHash(ctx context.Context) (string, error) {
+	var entries []entry
+	if err := s.db.WithContext(ctx).Order("id DESC").Limit(1).Find(&entries).Error; err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", nil
+	}
+	return entries[0].Hash, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	db, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+func mustJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// ParseTime parses an RFC 3339 timestamp, or a duration (e.g. 24h) meaning that long before now.
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected an RFC 3339 timestamp or a duration", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,80 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnauthenticated is returned when no authenticator accepts a token.
+var ErrUnauthenticated = errors.New("invalid bearer token")
+
+// Identity is who a network client authenticated as.
+type Identity struct {
+	Username string   `json:"username"`
+	UID      string   `json:"uid,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	// Method is the authenticator that accepted the token, token-file or jwt.
+	Method string `json:"method"`
+}
+
+// Authenticator resolves the identity a bearer token belongs to.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Identity, error)
+}
+
+// identityKey is how we find the authenticated identity of a request in a context.Context.
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying id.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFrom returns the identity carried by ctx, nil if the request wasn't authenticated.
+func IdentityFrom(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
+
+// union accepts a token as soon as one of its authenticators does.
+type union []Authenticator
+
+func (u union) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	var errs []error
+	for _, a := range u {
+		id, err := a.Authenticate(ctx, token)
+		if err == nil {
+			return id, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("%w: %w", ErrUnauthenticated, errors.Join(errs...))
+}
+
+// New builds the authenticators configured by opts. It returns nil if authentication is disabled.
+func New(opts *Options) (Authenticator, error) {
+	if opts == nil {
+		return nil, nil
+	}
+
+	var authenticators union
+	if opts.TokenFile != "" {
+		a, err := newTokenFile(opts.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	if opts.JWT != nil && opts.JWT.Issuer != "" {
+		a, err := newJWT(opts.JWT)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	if len(authenticators) == 0 {
+		return nil, nil
+	}
+	return authenticators, nil
+}
@@ -0,0 +1,337 @@
+package authn
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(t *testing.T, kid string, key *rsa.PublicKey) map[string]any {
+	t.Helper()
+	return map[string]any{"kty": "RSA", "kid": kid, "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func ecJWK(t *testing.T, kid string, key *ecdsa.PublicKey) map[string]any {
+	t.Helper()
+	return map[string]any{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(key.X.Bytes()), "y": b64(key.Y.Bytes())}
+}
+
+func jwks(t *testing.T, keys ...map[string]any) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edJWK := map[string]any{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(edPublic)}
+	offCurve := ecJWK(t, "off", &ecKey.PublicKey)
+	offCurve["y"] = b64(new(big.Int).Add(ecKey.Y, big.NewInt(1)).Bytes())
+	encryption := rsaJWK(t, "enc", &rsaKey.PublicKey)
+	encryption["use"] = "enc"
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    map[string]crypto.PublicKey
+		wantErr bool
+	}{
+		{
+			name: "rsa, ec and ed25519 keys",
+			data: jwks(t, rsaJWK(t, "rsa", &rsaKey.PublicKey), ecJWK(t, "ec", &ecKey.PublicKey), edJWK),
+			want: map[string]crypto.PublicKey{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey, "ed": edPublic},
+		},
+		{
+			name: "unusable keys are skipped",
+			data: jwks(t, rsaJWK(t, "rsa", &rsaKey.PublicKey), encryption, offCurve,
+				map[string]any{"kty": "oct", "kid": "hmac", "k": b64([]byte("secret"))},
+				map[string]any{"kty": "EC", "kid": "p224", "crv": "P-224", "x": "AQ", "y": "AQ"}),
+			want: map[string]crypto.PublicKey{"rsa": &rsaKey.PublicKey},
+		},
+		{
+			name:    "no usable key",
+			data:    jwks(t, encryption),
+			wantErr: true,
+		},
+		{
+			name:    "invalid JSON",
+			data:    []byte(`{"keys":`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJWKS(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJWKS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseJWKS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeySetKeyFunc(t *testing.T) {
+	first, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		// sets are the key sets returned by the successive loads
+		sets [][]byte
+		// stale makes the first load old enough to be refreshed
+		stale   bool
+		kid     string
+		want    crypto.PublicKey
+		wantErr bool
+	}{
+		{
+			name: "key by id",
+			sets: [][]byte{jwks(t, ecJWK(t, "first", &first.PublicKey), ecJWK(t, "second", &second.PublicKey))},
+			kid:  "second",
+			want: &second.PublicKey,
+		},
+		{
+			name: "only key without id",
+			sets: [][]byte{jwks(t, ecJWK(t, "first", &first.PublicKey))},
+			want: &first.PublicKey,
+		},
+		{
+			name:    "ambiguous key without id",
+			sets:    [][]byte{jwks(t, ecJWK(t, "first", &first.PublicKey), ecJWK(t, "second", &second.PublicKey))},
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			sets:    [][]byte{jwks(t, ecJWK(t, "first", &first.PublicKey))},
+			kid:     "unknown",
+			wantErr: true,
+		},
+		{
+			name: "rotated key after a refresh",
+			sets: [][]byte{
+				jwks(t, ecJWK(t, "first", &first.PublicKey)),
+				jwks(t, ecJWK(t, "first", &first.PublicKey), ecJWK(t, "rotated", &rotated.PublicKey)),
+			},
+			stale: true,
+			kid:   "rotated",
+			want:  &rotated.PublicKey,
+		},
+		{
+			name: "rotated key before the refresh interval",
+			sets: [][]byte{
+				jwks(t, ecJWK(t, "first", &first.PublicKey)),
+				jwks(t, ecJWK(t, "rotated", &rotated.PublicKey)),
+			},
+			kid:     "rotated",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loads := 0
+			ks := &keySet{load: func() ([]byte, error) {
+				data := tt.sets[min(loads, len(tt.sets)-1)]
+				loads++
+				return data, nil
+			}}
+			if err := ks.refresh(); err != nil {
+				t.Fatal(err)
+			}
+			if tt.stale {
+				ks.refreshedAt = time.Now().Add(-jwksRefreshInterval)
+			}
+			token := jwt.New(jwt.SigningMethodES256)
+			if tt.kid != "" {
+				token.Header["kid"] = tt.kid
+			}
+			got, err := ks.keyFunc(token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("keyFunc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keyFunc() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, jwks(t, rsaJWK(t, "key", &key.PublicKey)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := newJWT(&JWTOptions{
+		Issuer:         "https://issuer.example.com",
+		Audience:       "mcp-kubernetes",
+		JWKSFile:       jwksFile,
+		UsernameClaim:  "email",
+		UIDClaim:       "sub",
+		GroupsClaim:    "groups",
+		UsernamePrefix: "oidc:",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":    "https://issuer.example.com",
+			"aud":    []string{"other", "mcp-kubernetes"},
+			"exp":    now.Add(time.Hour).Unix(),
+			"sub":    "1234",
+			"email":  "alice@example.com",
+			"groups": []string{"dev", "ops"},
+		}
+	}
+	sign := func(method jwt.SigningMethod, signingKey any, kid string, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(signingKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	with := func(change func(jwt.MapClaims)) jwt.MapClaims {
+		claims := valid()
+		change(claims)
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    *Identity
+		wantErr bool
+	}{
+		{
+			name:  "valid token",
+			token: sign(jwt.SigningMethodRS256, key, "key", valid()),
+			want:  &Identity{Username: "oidc:alice@example.com", UID: "1234", Groups: []string{"dev", "ops"}, Method: methodJWT},
+		},
+		{
+			name:  "single group",
+			token: sign(jwt.SigningMethodRS256, key, "key", with(func(c jwt.MapClaims) { c["groups"] = "dev" })),
+			want:  &Identity{Username: "oidc:alice@example.com", UID: "1234", Groups: []string{"dev"}, Method: methodJWT},
+		},
+		{
+			name:  "expired within the leeway",
+			token: sign(jwt.SigningMethodRS256, key, "key", with(func(c jwt.MapClaims) { c["exp"] = now.Add(-10 * time.Second).Unix() })),
+			want:  &Identity{Username: "oidc:alice@example.com", UID: "1234", Groups: []string{"dev", "ops"}, Method: methodJWT},
+		},
+		{
+			name:    "other issuer",
+			token:   sign(jwt.SigningMethodRS256, key, "key", with(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" })),
+			wantErr: true,
+		},
+		{
+			name:    "other audience",
+			token:   sign(jwt.SigningMethodRS256, key, "key", with(func(c jwt.MapClaims) { c["aud"] = "other" })),
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			token:   sign(jwt.SigningMethodRS256, key, "key", with(func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Hour).Unix() })),
+			wantErr: true,
+		},
+		{
+			name:    "without expiry",
+			token:   sign(jwt.SigningMethodRS256, key, "key", with(func(c jwt.MapClaims) { delete(c, "exp") })),
+			wantErr: true,
+		},
+		{
+			name:    "not valid yet",
+			token:   sign(jwt.SigningMethodRS256, key, "key", with(func(c jwt.MapClaims) { c["nbf"] = now.Add(time.Hour).Unix() })),
+			wantErr: true,
+		},
+		{
+			name:    "without username",
+			token:   sign(jwt.SigningMethodRS256, key, "key", with(func(c jwt.MapClaims) { delete(c, "email") })),
+			wantErr: true,
+		},
+		{
+			name:    "signed by another key",
+			token:   sign(jwt.SigningMethodRS256, other, "key", valid()),
+			wantErr: true,
+		},
+		{
+			name:    "unknown key id",
+			token:   sign(jwt.SigningMethodRS256, key, "unknown", valid()),
+			wantErr: true,
+		},
+		{
+			name:    "symmetric signature",
+			token:   sign(jwt.SigningMethodHS256, []byte("secret"), "key", valid()),
+			wantErr: true,
+		},
+		{
+			name:    "malformed token",
+			token:   "not-a-jwt",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Authenticate(context.Background(), tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Authenticate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package authn
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+type Options struct {
+	// TokenFile is a CSV file of static tokens: token,username,uid,"group1,group2".
+	TokenFile string      `json:"token-file,omitempty" mapstructure:"token-file"`
+	JWT       *JWTOptions `json:"jwt" mapstructure:"jwt"`
+}
+
+// JWTOptions configures the validation of JSON Web Tokens.
+type JWTOptions struct {
+	// Issuer must match the iss claim. JWT authentication is disabled if empty.
+	Issuer string `json:"issuer,omitempty" mapstructure:"issuer"`
+	// Audience must be one of the values of the aud claim.
+	Audience string `json:"audience,omitempty" mapstructure:"audience"`
+	// JWKSFile and JWKSURL locate the JSON Web Key Set verifying the signatures.
+	JWKSFile string `json:"jwks-file,omitempty" mapstructure:"jwks-file"`
+	JWKSURL  string `json:"jwks-url,omitempty" mapstructure:"jwks-url"`
+	// UsernameClaim, UIDClaim and GroupsClaim map claims to the identity.
+	UsernameClaim string `json:"username-claim" mapstructure:"username-claim"`
+	UIDClaim      string `json:"uid-claim" mapstructure:"uid-claim"`
+	GroupsClaim   string `json:"groups-claim" mapstructure:"groups-claim"`
+	// UsernamePrefix is prepended to usernames, e.g. oidc: to tell them apart from static tokens.
+	UsernamePrefix string `json:"username-prefix,omitempty" mapstructure:"username-prefix"`
+}
+
+func NewOptions() *Options {
+	return &Options{
+		JWT: &JWTOptions{
+			UsernameClaim: "sub",
+			UIDClaim:      "sub",
+			GroupsClaim:   "groups",
+		},
+	}
+}
+
+// Enabled reports whether any authenticator is configured.
+func (o *Options) Enabled() bool {
+	return o.TokenFile != "" || o.JWT.Issuer != ""
+}
+
+func (o *Options) Validate() []error {
+	errs := []error{}
+	if o.JWT.Issuer == "" {
+		return errs
+	}
+	if o.JWT.Audience == "" {
+		errs = append(errs, fmt.Errorf("--authn.jwt.audience is required with --authn.jwt.issuer"))
+	}
+	if (o.JWT.JWKSFile == "") == (o.JWT.JWKSURL == "") {
+		errs = append(errs, fmt.Errorf("exactly one of --authn.jwt.jwks-file and --authn.jwt.jwks-url is required with --authn.jwt.issuer"))
+	}
+	if o.JWT.UsernameClaim == "" {
+		errs = append(errs, fmt.Errorf("--authn.jwt.username-claim cannot be empty"))
+	}
+	return errs
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.TokenFile, "authn.token-file", o.TokenFile, "CSV `FILE` of static bearer tokens accepted by the SSE and HTTP transports, "+
+		`one token,username,uid,"group1,group2" per line.`)
+	fs.StringVar(&o.JWT.Issuer, "authn.jwt.issuer", o.JWT.Issuer, "Issuer of the JSON Web Tokens accepted by the SSE and HTTP transports. "+
+		"JWT authentication is disabled if empty.")
+	fs.StringVar(&o.JWT.Audience, "authn.jwt.audience", o.JWT.Audience, "Audience the JSON Web Tokens must be issued for.")
+	fs.StringVar(&o.JWT.JWKSFile, "authn.jwt.jwks-file", o.JWT.JWKSFile, "`FILE` holding the JSON Web Key Set verifying the tokens.")
+	fs.StringVar(&o.JWT.JWKSURL, "authn.jwt.jwks-url", o.JWT.JWKSURL, "`URL` serving the JSON Web Key Set verifying the tokens.")
+	fs.StringVar(&o.JWT.UsernameClaim, "authn.jwt.username-claim", o.JWT.UsernameClaim, "Claim holding the username.")
+	fs.StringVar(&o.JWT.UIDClaim, "authn.jwt.uid-claim", o.JWT.UIDClaim, "Claim holding the user UID.")
+	fs.StringVar(&o.JWT.GroupsClaim, "authn.jwt.groups-claim", o.JWT.GroupsClaim, "Claim holding the groups of the user, a string or a list of strings.")
+	fs.StringVar(&o.JWT.UsernamePrefix, "authn.jwt.username-prefix", o.JWT.UsernamePrefix, "Prefix of the usernames read from tokens (e.g. oidc:).")
+}
@@ -0,0 +1,69 @@
+package authn
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const methodTokenFile = "token-file"
+
+type tokenEntry struct {
+	token    string
+	identity *Identity
+}
+
+// tokenFile accepts the static tokens of a CSV file.
+type tokenFile struct {
+	entries []tokenEntry
+}
+
+func newTokenFile(path string) (*tokenFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open token file: %w", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file %s: %w", path, err)
+	}
+
+	tf := &tokenFile{}
+	for i, record := range records {
+		if len(record) < 2 || record[0] == "" || record[1] == "" {
+			return nil, fmt.Errorf("token file %s, line %d: a token and a username are required", path, i+1)
+		}
+		identity := &Identity{Username: record[1], Method: methodTokenFile}
+		if len(record) > 2 {
+			identity.UID = record[2]
+		}
+		if len(record) > 3 && record[3] != "" {
+			identity.Groups = strings.Split(record[3], ",")
+		}
+		tf.entries = append(tf.entries, tokenEntry{token: record[0], identity: identity})
+	}
+	return tf, nil
+}
+
+func (tf *tokenFile) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	var found *Identity
+	for _, entry := range tf.entries {
+		// Compare every entry in constant time so that timing doesn't reveal tokens
+		if subtle.ConstantTimeCompare([]byte(entry.token), []byte(token)) == 1 && found == nil {
+			found = entry.identity
+		}
+	}
+	if found == nil {
+		return nil, errors.New("unknown static token")
+	}
+	return found, nil
+}
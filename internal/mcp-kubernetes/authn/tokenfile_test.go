@@ -0,0 +1,118 @@
+package authn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTokenFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		token   string
+		want    *Identity
+		wantErr bool
+		// wantLoadErr is set when the file itself must be rejected
+		wantLoadErr bool
+	}{
+		{
+			name:    "token and username",
+			content: "alice-token,alice\n",
+			token:   "alice-token",
+			want:    &Identity{Username: "alice", Method: methodTokenFile},
+		},
+		{
+			name:    "uid and groups",
+			content: "alice-token,alice,1,\"dev,ops\"\n",
+			token:   "alice-token",
+			want:    &Identity{Username: "alice", UID: "1", Groups: []string{"dev", "ops"}, Method: methodTokenFile},
+		},
+		{
+			name:    "empty groups",
+			content: "alice-token,alice,1,\n",
+			token:   "alice-token",
+			want:    &Identity{Username: "alice", UID: "1", Method: methodTokenFile},
+		},
+		{
+			name:    "comments and several users",
+			content: "# token,user,uid,groups\nalice-token,alice,1\nbob-token,bob,2,admins\n",
+			token:   "bob-token",
+			want:    &Identity{Username: "bob", UID: "2", Groups: []string{"admins"}, Method: methodTokenFile},
+		},
+		{
+			name:    "first of duplicate tokens",
+			content: "shared,alice\nshared,bob\n",
+			token:   "shared",
+			want:    &Identity{Username: "alice", Method: methodTokenFile},
+		},
+		{
+			name:    "unknown token",
+			content: "alice-token,alice\n",
+			token:   "bob-token",
+			wantErr: true,
+		},
+		{
+			name:    "prefix of a token",
+			content: "alice-token,alice\n",
+			token:   "alice",
+			wantErr: true,
+		},
+		{
+			name:    "empty token",
+			content: "alice-token,alice\n",
+			token:   "",
+			wantErr: true,
+		},
+		{
+			name:        "missing username",
+			content:     "alice-token\n",
+			wantLoadErr: true,
+		},
+		{
+			name:        "empty username",
+			content:     "alice-token,\n",
+			wantLoadErr: true,
+		},
+		{
+			name:        "empty token column",
+			content:     ",alice\n",
+			wantLoadErr: true,
+		},
+		{
+			name:        "malformed CSV",
+			content:     "alice-token,\"alice\n",
+			wantLoadErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tokens.csv")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			tf, err := newTokenFile(path)
+			if (err != nil) != tt.wantLoadErr {
+				t.Fatalf("newTokenFile() error = %v, wantLoadErr %v", err, tt.wantLoadErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := tf.Authenticate(context.Background(), tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Authenticate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenFileMissing(t *testing.T) {
+	if _, err := newTokenFile(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Error("newTokenFile() of a missing file succeeded")
+	}
+}
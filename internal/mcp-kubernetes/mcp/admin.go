@@ -0,0 +1,150 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/authn"
+	"github.com/fleezesd/mcp-kubernetes/pkg/log"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"sigs.k8s.io/yaml"
+)
+
+// sessionInfo is what session_list reports about a session.
+type sessionInfo struct {
+	ID         string    `json:"id"`
+	Transport  string    `json:"transport"`
+	Client     string    `json:"client,omitempty"`
+	User       string    `json:"user,omitempty"`
+	Context    string    `json:"context,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	LastActive time.Time `json:"lastActive"`
+	Calls      int       `json:"calls"`
+	Terminable bool      `json:"terminable"`
+	Current    bool      `json:"current,omitempty"`
+}
+
+// list returns the sessions, oldest first.
+func (ss *sessions) list() []sessionInfo {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	infos := make([]sessionInfo, 0, len(ss.items))
+	for id, sess := range ss.items {
+		info := sessionInfo{
+			ID:         id,
+			Transport:  sess.transport,
+			Client:     strings.TrimSuffix(sess.clientName+"/"+sess.clientVersion, "/"),
+			Context:    sess.kubeContext,
+			Namespace:  sess.namespace,
+			StartedAt:  sess.startedAt.UTC(),
+			LastActive: sess.lastActive.UTC(),
+			Calls:      sess.calls,
+			Terminable: sess.close != nil,
+		}
+		if sess.identity != nil {
+			info.User = sess.identity.Username
+		}
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b sessionInfo) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	return infos
+}
+
+func (ss *sessions) closer(id string) (func(), bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	sess, ok := ss.items[id]
+	if !ok {
+		return nil, false
+	}
+	return sess.close, true
+}
+
+func (s *Server) initSessions() []server.ServerTool {
+	if len(s.configuration.SessionAdmins) == 0 {
+		return nil
+	}
+	return []server.ServerTool{
+		{
+			Tool: mcp.NewTool("session_list",
+				mcp.WithDescription("List the active MCP sessions of the server with their transport, client, user, "+
+					"context, default namespace, start time, last activity and number of tool calls"),
+				mcp.WithToolAnnotation(mcp.ToolAnnotation{
+					Title:           "Sessions: List",
+					ReadOnlyHint:    true,
+					DestructiveHint: false,
+					IdempotentHint:  true,
+					OpenWorldHint:   false,
+				})),
+			Handler: s.sessionList,
+		},
+		{
+			Tool: mcp.NewTool("session_terminate",
+				mcp.WithDescription("Terminate an active MCP session of the server, its client has to open a new one"),
+				mcp.WithString("session", mcp.Required(), mcp.Description("ID of the session to terminate, as listed by session_list")),
+				mcp.WithToolAnnotation(mcp.ToolAnnotation{
+					Title:           "Sessions: Terminate",
+					ReadOnlyHint:    false,
+					DestructiveHint: true,
+					IdempotentHint:  true,
+					OpenWorldHint:   false,
+				})),
+			Handler: s.sessionTerminate,
+		},
+	}
+}
+
+// isSessionAdmin reports whether the caller of ctx may manage the sessions of the server:
+// the stdio client, which runs as the user who started the server, or a user
+// authenticated as one of the session admins.
+func (s *Server) isSessionAdmin(ctx context.Context) bool {
+	if cs := server.ClientSessionFromContext(ctx); cs != nil && cs.SessionID() == stdioSessionID {
+		return true
+	}
+	identity := authn.IdentityFrom(ctx)
+	return identity != nil && matchesAny(s.configuration.SessionAdmins, identity.Username)
+}
+
+func (s *Server) sessionList(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if !s.isSessionAdmin(ctx) {
+		return NewTextResult("", fmt.Errorf("failed to list sessions: not a session admin")), nil
+	}
+	infos := s.sessions.list()
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		for i := range infos {
+			infos[i].Current = infos[i].ID == cs.SessionID()
+		}
+	}
+	result, err := yaml.Marshal(infos)
+	if err != nil {
+		return NewTextResult("", fmt.Errorf("failed to list sessions: %v", err)), nil
+	}
+	return NewTextResult(string(result), nil), nil
+}
+
+func (s *Server) sessionTerminate(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if !s.isSessionAdmin(ctx) {
+		return NewTextResult("", fmt.Errorf("failed to terminate session: not a session admin")), nil
+	}
+	id, _ := ctr.Params.Arguments["session"].(string)
+	if id == "" {
+		return NewTextResult("", fmt.Errorf("failed to terminate session, missing argument session")), nil
+	}
+	closeSession, ok := s.sessions.closer(id)
+	if !ok {
+		return NewTextResult("", fmt.Errorf("failed to terminate session: session %s not found", id)), nil
+	}
+	if closeSession == nil {
+		return NewTextResult("", fmt.Errorf("failed to terminate session: the %s session can't be terminated", id)), nil
+	}
+	log.C(ctx).Infow("Terminating session", "session", id)
+	closeSession()
+	return NewTextResult(fmt.Sprintf("Session %s terminated", id), nil), nil
+}
@@ -0,0 +1,231 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/audit"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/authn"
+	"github.com/fleezesd/mcp-kubernetes/pkg/kubernetes"
+	"github.com/fleezesd/mcp-kubernetes/pkg/log"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"sigs.k8s.io/yaml"
+)
+
+// sensitiveArguments are fragments of argument names whose values are never audited.
+var sensitiveArguments = []string{"token", "password", "secret"}
+
+func (s *Server) initAudit() []server.ServerTool {
+	if s.audit.Store() == nil {
+		return nil
+	}
+	tools := []server.ServerTool{
+		{
+			Tool: mcp.NewTool("audit_query",
+				mcp.WithDescription("Query the audit records of past tool calls, most recent first. "+
+					"Only session admins can query the calls of other users"),
+				mcp.WithString("user", mcp.Description("Only return the calls of this Kubernetes or authenticated username (Optional)")),
+				mcp.WithString("tool", mcp.Description("Only return the calls of this tool (Optional)")),
+				mcp.WithString("namespace", mcp.Description("Only return the calls targeting this namespace (Optional)")),
+				mcp.WithString("since", mcp.Description("Only return the calls made at or after this time, "+
+					"either an RFC 3339 timestamp or a duration before now (e.g. 24h) (Optional)")),
+				mcp.WithString("until", mcp.Description("Only return the calls made at or before this time, "+
+					"either an RFC 3339 timestamp or a duration before now (e.g. 1h) (Optional)")),
+				mcp.WithNumber("limit", mcp.Description("Maximum number of records to return, 100 if omitted (Optional)")),
+				mcp.WithToolAnnotation(mcp.ToolAnnotation{
+					Title:           "Audit: Query",
+					ReadOnlyHint:    true,
+					DestructiveHint: false,
+					IdempotentHint:  true,
+					OpenWorldHint:   false,
+				})),
+			Handler: s.auditQuery,
+		},
+	}
+	return tools
+}
+
+func (s *Server) auditQuery(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	q := audit.Query{}
+	q.Username, _ = ctr.Params.Arguments["user"].(string)
+	q.Tool, _ = ctr.Params.Arguments["tool"].(string)
+	q.Namespace, _ = ctr.Params.Arguments["namespace"].(string)
+	if limit, ok := ctr.Params.Arguments["limit"].(float64); ok {
+		q.Limit = int(limit)
+	}
+	now := time.Now()
+	since, _ := ctr.Params.Arguments["since"].(string)
+	until, _ := ctr.Params.Arguments["until"].(string)
+	var err error
+	if q.Since, err = audit.ParseTime(since, now); err != nil {
+		return NewTextResult("", fmt.Errorf("failed to query audit records, since: %v", err)), nil
+	}
+	if q.Until, err = audit.ParseTime(until, now); err != nil {
+		return NewTextResult("", fmt.Errorf("failed to query audit records, until: %v", err)), nil
+	}
+
+	// Only session admins see the calls of everyone
+	if !s.isSessionAdmin(ctx) {
+		username, err := s.auditCaller(ctx)
+		if err != nil {
+			return NewTextResult("", fmt.Errorf("failed to query audit records: %v", err)), nil
+		}
+		if q.Username != "" && q.Username != username {
+			return NewTextResult("", fmt.Errorf("failed to query audit records: only session admins can query the calls of other users")), nil
+		}
+		q.Username = username
+	}
+
+	records, err := s.audit.Store().Query(ctx, q)
+	if err != nil {
+		return NewTextResult("", fmt.Errorf("failed to query audit records: %v", err)), nil
+	}
+	if len(records) == 0 {
+		return NewTextResult("No audit records found", nil), nil
+	}
+	result, err := yaml.Marshal(records)
+	if err != nil {
+		return NewTextResult("", fmt.Errorf("failed to query audit records: %v", err)), nil
+	}
+	return NewTextResult(string(result), nil), nil
+}
+
+// auditCaller returns the username the calls of the caller of ctx are audited with: the
+// authenticated principal, or the Kubernetes user of its own credentials with token passthrough.
+func (s *Server) auditCaller(ctx context.Context) (string, error) {
+	if identity := authn.IdentityFrom(ctx); identity != nil {
+		return identity.Username, nil
+	}
+	if s.configuration.TokenPassthrough {
+		userInfo, err := s.userInfo(ctx)
+		if err != nil {
+			return "", err
+		}
+		return userInfo.Username, nil
+	}
+	return "", fmt.Errorf("the caller is neither authenticated nor a session admin")
+}
+
+// withAudit is a tool handler middleware that records every tool call, whatever
+// its outcome, in the audit log.
+func (s *Server) withAudit(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if s.audit == nil {
+			return next(ctx, ctr)
+		}
+
+		start := time.Now()
+		result, err := next(ctx, ctr)
+
+		record := &audit.Record{
+			Timestamp:  start.UTC(),
+			Tool:       ctr.Params.Name,
+			Arguments:  auditArguments(ctr.Params.Arguments),
+			Targets:    s.auditTargets(ctx, ctr),
+			Outcome:    audit.OutcomeSuccess,
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if cs := server.ClientSessionFromContext(ctx); cs != nil {
+			record.SessionID = cs.SessionID()
+			if name, version := s.sessions.client(cs.SessionID()); name != "" || version != "" {
+				record.Client = &audit.Client{Name: name, Version: version}
+			}
+		}
+		if identity := authn.IdentityFrom(ctx); identity != nil {
+			record.Principal = &audit.User{Username: identity.Username, UID: identity.UID, Groups: identity.Groups}
+		}
+		if userInfo, err := s.userInfo(ctx); err == nil {
+			record.User = &audit.User{Username: userInfo.Username, UID: userInfo.UID, Groups: userInfo.Groups}
+		}
+		switch cause := context.Cause(ctx); {
+		case cause != nil:
+			// withDeadline reports the abort to the client after this middleware returns
+			record.Outcome, record.Error = audit.OutcomeError, fmt.Sprintf("%s aborted: %v", ctr.Params.Name, cause)
+		case err != nil:
+			record.Outcome, record.Error = audit.OutcomeError, err.Error()
+		case result != nil && result.IsError:
+			record.Outcome, record.Error = audit.OutcomeError, resultText(result)
+		}
+
+		if err := s.audit.Log(record); err != nil {
+			log.C(ctx).Errorw(err, "Failed to write audit record", "tool", ctr.Params.Name)
+		}
+		return result, err
+	}
+}
+
+// auditTargets derives the objects a tool call acts on from its arguments.
+func (s *Server) auditTargets(ctx context.Context, ctr mcp.CallToolRequest) []audit.Target {
+	target := audit.Target{}
+	target.APIVersion, _ = ctr.Params.Arguments["apiVersion"].(string)
+	target.Kind, _ = ctr.Params.Arguments["kind"].(string)
+	target.Name, _ = ctr.Params.Arguments["name"].(string)
+	target.Namespace, _ = ctr.Params.Arguments["namespace"].(string)
+	if obj := objectArgument(ctr.Params.Arguments["resource"]); obj != nil {
+		target.APIVersion, _ = obj["apiVersion"].(string)
+		target.Kind, _ = obj["kind"].(string)
+		if metadata, ok := obj["metadata"].(map[string]any); ok {
+			target.Name, _ = metadata["name"].(string)
+			target.Namespace, _ = metadata["namespace"].(string)
+		}
+	}
+	if target == (audit.Target{}) {
+		return nil
+	}
+	if target.Namespace == "" {
+		if k, err := s.kubernetes(ctx); err == nil {
+			target.Namespace = k.DefaultNamespace(ctx)
+		}
+	}
+	return []audit.Target{target}
+}
+
+// auditArguments returns a copy of the arguments with credentials and Secret values masked.
+func auditArguments(arguments map[string]any) map[string]any {
+	if len(arguments) == 0 {
+		return nil
+	}
+	masked := make(map[string]any, len(arguments))
+	for key, value := range arguments {
+		if _, isBool := value.(bool); !isBool && isSensitiveArgument(key) {
+			masked[key] = "REDACTED"
+			continue
+		}
+		masked[key] = value
+	}
+	if obj := objectArgument(arguments["resource"]); obj != nil {
+		// objectArgument may return the argument itself, which must be left untouched
+		copied := map[string]any{}
+		if b, err := json.Marshal(obj); err != nil || json.Unmarshal(b, &copied) != nil {
+			masked["resource"] = "REDACTED"
+		} else {
+			kubernetes.MaskSecret(copied)
+			masked["resource"] = copied
+		}
+	}
+	return masked
+}
+
+func isSensitiveArgument(key string) bool {
+	key = strings.ToLower(key)
+	for _, fragment := range sensitiveArguments {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	texts := make([]string, 0, len(result.Content))
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,122 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/authn"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"sigs.k8s.io/yaml"
+)
+
+func (s *Server) initAuth() []server.ServerTool {
+	tools := []server.ServerTool{
+		{
+			Tool: mcp.NewTool("auth_whoami",
+				mcp.WithDescription("Get the username, UID, groups and extra attributes the Kubernetes API server "+
+					"sees for the current credentials, together with the context, cluster and impersonation settings in effect, "+
+					"and the principal the client authenticated as, if any"),
+				mcp.WithToolAnnotation(mcp.ToolAnnotation{
+					Title:           "Auth: Who Am I",
+					ReadOnlyHint:    true,
+					DestructiveHint: false,
+					IdempotentHint:  true,
+					OpenWorldHint:   true,
+				})),
+			Handler: s.authWhoAmI,
+		},
+		{
+			Tool: mcp.NewTool("auth_can_i",
+				mcp.WithDescription("Check whether the current user is allowed to perform an action on a Kubernetes resource"),
+				mcp.WithString("verb", mcp.Required(), mcp.Description("Verb to check (e.g. get, list, watch, create, update, patch, delete, *)")),
+				mcp.WithString("resource", mcp.Required(), mcp.Description("Resource to check, optionally qualified with its API group "+
+					"(e.g. pods, deployments.apps, *)")),
+				mcp.WithString("subresource", mcp.Description("Subresource to check (e.g. log, exec, scale) (Optional)")),
+				mcp.WithString("name", mcp.Description("Name of the resource to check. If omitted, all resources of the type are checked (Optional)")),
+				mcp.WithString("namespace", mcp.Description("Namespace to check. "+
+					"If omitted, namespaced resources are checked in the default namespace (Optional)")),
+				mcp.WithToolAnnotation(mcp.ToolAnnotation{
+					Title:           "Auth: Can I",
+					ReadOnlyHint:    true,
+					DestructiveHint: false,
+					IdempotentHint:  true,
+					OpenWorldHint:   true,
+				})),
+			Handler: s.authCanI,
+		},
+		{
+			Tool: mcp.NewTool("auth_rules",
+				mcp.WithDescription("List the resource and non-resource rules the current user is allowed to use in a namespace"),
+				mcp.WithString("namespace", mcp.Description("Namespace to list the rules for. "+
+					"If omitted, the default namespace is used (Optional)")),
+				mcp.WithToolAnnotation(mcp.ToolAnnotation{
+					Title:           "Auth: Rules",
+					ReadOnlyHint:    true,
+					DestructiveHint: false,
+					IdempotentHint:  true,
+					OpenWorldHint:   true,
+				})),
+			Handler: s.authRules,
+		},
+	}
+	return tools
+}
+
+func (s *Server) authWhoAmI(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	k, err := s.kubernetes(ctx)
+	if err != nil {
+		return NewTextResult("", err), nil
+	}
+	result, err := k.WhoAmI(ctx)
+	if err != nil {
+		return NewTextResult("", fmt.Errorf("failed to review current user: %v", err)), nil
+	}
+	if identity := authn.IdentityFrom(ctx); identity != nil {
+		principal, err := yaml.Marshal(map[string]*authn.Identity{"principal": identity})
+		if err != nil {
+			return NewTextResult("", fmt.Errorf("failed to review current user: %v", err)), nil
+		}
+		result += string(principal)
+	}
+	return NewTextResult(result, nil), nil
+}
+
+func (s *Server) authCanI(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	verb, _ := ctr.Params.Arguments["verb"].(string)
+	if verb == "" {
+		return NewTextResult("", errors.New("failed to check access, missing argument verb")), nil
+	}
+	resource, _ := ctr.Params.Arguments["resource"].(string)
+	if resource == "" {
+		return NewTextResult("", errors.New("failed to check access, missing argument resource")), nil
+	}
+	subresource, _ := ctr.Params.Arguments["subresource"].(string)
+	name, _ := ctr.Params.Arguments["name"].(string)
+	namespace, _ := ctr.Params.Arguments["namespace"].(string)
+
+	k, err := s.kubernetes(ctx)
+	if err != nil {
+		return NewTextResult("", err), nil
+	}
+	result, err := k.CanI(ctx, verb, resource, subresource, name, namespace)
+	if err != nil {
+		err = fmt.Errorf("failed to check access: %v", err)
+	}
+	return NewTextResult(result, err), nil
+}
+
+func (s *Server) authRules(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	namespace, _ := ctr.Params.Arguments["namespace"].(string)
+
+	k, err := s.kubernetes(ctx)
+	if err != nil {
+		return NewTextResult("", err), nil
+	}
+	result, err := k.Rules(ctx, namespace)
+	if err != nil {
+		err = fmt.Errorf("failed to list rules: %v", err)
+	}
+	return NewTextResult(result, err), nil
+}
@@ -12,11 +12,19 @@ func (s *Server) initConfiguration() []server.ServerTool {
 	tools := []server.ServerTool{
 		{
 			Tool: mcp.NewTool("configuration_view",
-				mcp.WithDescription("Get the current Kubernetes configuration content as a kubeconfig YAML"),
+				mcp.WithDescription("Get the current Kubernetes configuration content as a kubeconfig YAML. "+
+					"Credentials such as tokens, passwords and client keys are replaced with REDACTED unless the server allows revealing them"),
 				mcp.WithBoolean("minified", mcp.Description("Return a minified version of the configuration. "+
 					"If set to true, keeps only the current-context and the relevant pieces of the configuration for that context. "+
 					"If set to false, all contexts, clusters, auth-infos, and users are returned in the configuration. "+
-					"(Optional, default true)"))),
+					"(Optional, default true)")),
+				mcp.WithToolAnnotation(mcp.ToolAnnotation{
+					Title:           "Configuration: View",
+					ReadOnlyHint:    true,
+					DestructiveHint: false,
+					IdempotentHint:  true,
+					OpenWorldHint:   true,
+				})),
 			Handler: s.configurationView,
 		},
 	}
@@ -29,7 +37,11 @@ func (s *Server) configurationView(ctx context.Context, ctr mcp.CallToolRequest)
 	if _, ok := minified.(bool); ok {
 		minify = minified.(bool)
 	}
-	result, err := s.k.ConfigurationView(minify)
+	k, err := s.kubernetes(ctx)
+	if err != nil {
+		return NewTextResult("", err), nil
+	}
+	result, err := k.ConfigurationView(minify, !s.configuration.RevealCredentials)
 	if err != nil {
 		err = fmt.Errorf("failed to get configuration: %v", err)
 	}
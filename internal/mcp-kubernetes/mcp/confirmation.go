@@ -0,0 +1,155 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"sigs.k8s.io/yaml"
+)
+
+// confirmationTokenArgument is the argument a client sets to execute a planned destructive call.
+const confirmationTokenArgument = "confirmation_token"
+
+// confirmation is a destructive call that was planned but not executed yet.
+type confirmation struct {
+	sessionID string
+	tool      string
+	digest    string
+	expires   time.Time
+}
+
+type confirmations struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	items map[string]*confirmation
+}
+
+func newConfirmations(ttl time.Duration) *confirmations {
+	return &confirmations{ttl: ttl, items: make(map[string]*confirmation)}
+}
+
+// issue stores the planned call and returns the token that confirms it.
+func (c *confirmations) issue(pending *confirmation) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for t, item := range c.items {
+		if now.After(item.expires) {
+			delete(c.items, t)
+		}
+	}
+	pending.expires = now.Add(c.ttl)
+	c.items[token] = pending
+	return token, nil
+}
+
+// consume checks that token confirms exactly the given call and invalidates it.
+func (c *confirmations) consume(token string, call *confirmation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pending, ok := c.items[token]
+	if !ok {
+		return errors.New("unknown or already used confirmation token")
+	}
+	if pending.sessionID != call.sessionID || pending.tool != call.tool || pending.digest != call.digest {
+		return errors.New("confirmation token was issued for a different call, the tool and arguments must match the plan exactly")
+	}
+	delete(c.items, token)
+	if time.Now().After(pending.expires) {
+		return errors.New("confirmation token expired, call the tool again without it to get a new plan")
+	}
+	return nil
+}
+
+// plan is what a client is shown before a destructive call is executed.
+type plan struct {
+	Tool              string         `json:"tool"`
+	Arguments         map[string]any `json:"arguments,omitempty"`
+	ConfirmationToken string         `json:"confirmationToken"`
+	ExpiresAt         time.Time      `json:"expiresAt"`
+}
+
+// withConfirmation is a tool handler middleware that turns the first call of a
+// destructive tool, or of any tool a policy wants confirmed, into a plan with a
+// short-lived confirmation token. The call is only executed when repeated in the
+// same session with the same arguments and the token.
+func (s *Server) withConfirmation(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		annotations := s.toolAnnotations(ctr.Params.Name)
+		destructive := !annotations.ReadOnlyHint && annotations.DestructiveHint
+		if !(s.configuration.ConfirmDestructive && destructive) && !confirmationRequired(ctx) {
+			return next(ctx, ctr)
+		}
+
+		cs := server.ClientSessionFromContext(ctx)
+		if cs == nil {
+			return NewTextResult("", errors.New("no MCP session found for the request")), nil
+		}
+		arguments := maps.Clone(ctr.Params.Arguments)
+		token, _ := arguments[confirmationTokenArgument].(string)
+		delete(arguments, confirmationTokenArgument)
+		digest, err := argumentsDigest(arguments)
+		if err != nil {
+			return NewTextResult("", fmt.Errorf("failed to plan %s: %v", ctr.Params.Name, err)), nil
+		}
+		call := &confirmation{sessionID: cs.SessionID(), tool: ctr.Params.Name, digest: digest}
+
+		if token != "" {
+			if err := s.confirmations.consume(token, call); err != nil {
+				return NewTextResult("", err), nil
+			}
+			ctr.Params.Arguments = arguments
+			return next(ctx, ctr)
+		}
+
+		token, err = s.confirmations.issue(call)
+		if err != nil {
+			return NewTextResult("", fmt.Errorf("failed to issue confirmation token: %v", err)), nil
+		}
+		planned, err := yaml.Marshal(plan{
+			Tool:              ctr.Params.Name,
+			Arguments:         auditArguments(arguments),
+			ConfirmationToken: token,
+			ExpiresAt:         call.expires,
+		})
+		if err != nil {
+			return NewTextResult("", fmt.Errorf("failed to plan %s: %v", ctr.Params.Name, err)), nil
+		}
+		return NewTextResult(fmt.Sprintf("The call was NOT executed. Review the plan below and call %s again with "+
+			"exactly the same arguments and %s set to the token to execute it.\n\n%s",
+			ctr.Params.Name, confirmationTokenArgument, planned), nil), nil
+	}
+}
+
+func argumentsDigest(arguments map[string]any) (string, error) {
+	// json sorts map keys, so equal arguments always produce the same digest
+	b, err := json.Marshal(arguments)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// withConfirmationTokenArgument declares the confirmation token argument on a tool.
+func withConfirmationTokenArgument(tool *mcp.Tool) {
+	mcp.WithString(confirmationTokenArgument, mcp.Description("Token returned by the plan of a previous call "+
+		"with the same arguments. If omitted, the call is not executed and a plan with a confirmation token is returned instead "+
+		"(Optional)"))(tool)
+}
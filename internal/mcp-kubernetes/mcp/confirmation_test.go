@@ -0,0 +1,123 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"sigs.k8s.io/yaml"
+)
+
+func TestWithConfirmation(t *testing.T) {
+	type call struct {
+		session string
+		text    string
+		// token is the index of the earlier call whose plan token is presented, -1 for none
+		token int
+		// wait is how long to wait before the call
+		wait         time.Duration
+		wantExecuted bool
+		wantPlan     bool
+		wantError    string
+	}
+	tests := []struct {
+		name  string
+		ttl   time.Duration
+		calls []call
+	}{
+		{
+			name: "plan then confirm",
+			ttl:  time.Minute,
+			calls: []call{
+				{session: "a", text: "x", token: -1, wantPlan: true},
+				{session: "a", text: "x", token: 0, wantExecuted: true},
+			},
+		},
+		{
+			name: "token used twice",
+			ttl:  time.Minute,
+			calls: []call{
+				{session: "a", text: "x", token: -1, wantPlan: true},
+				{session: "a", text: "x", token: 0, wantExecuted: true},
+				{session: "a", text: "x", token: 0, wantError: "unknown or already used"},
+			},
+		},
+		{
+			name: "other arguments",
+			ttl:  time.Minute,
+			calls: []call{
+				{session: "a", text: "x", token: -1, wantPlan: true},
+				{session: "a", text: "y", token: 0, wantError: "different call"},
+			},
+		},
+		{
+			name: "other session",
+			ttl:  time.Minute,
+			calls: []call{
+				{session: "a", text: "x", token: -1, wantPlan: true},
+				{session: "b", text: "x", token: 0, wantError: "different call"},
+			},
+		},
+		{
+			name: "expired token",
+			ttl:  10 * time.Millisecond,
+			calls: []call{
+				{session: "a", text: "x", token: -1, wantPlan: true},
+				{session: "a", text: "x", token: 0, wait: 50 * time.Millisecond, wantError: "expired"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, Configuration{ConfirmDestructive: true})
+			s.confirmations = newConfirmations(tt.ttl)
+			s.tools = map[string]mcp.ToolAnnotation{"destroy": {DestructiveHint: true}}
+			executed := false
+			handler := s.withConfirmation(func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				if _, ok := ctr.Params.Arguments[confirmationTokenArgument]; ok {
+					t.Error("the confirmation token was passed to the tool")
+				}
+				executed = true
+				return NewTextResult("destroyed", nil), nil
+			})
+
+			var tokens []string
+			for i, c := range tt.calls {
+				time.Sleep(c.wait)
+				executed = false
+				ctr := mcp.CallToolRequest{}
+				ctr.Params.Name = "destroy"
+				ctr.Params.Arguments = map[string]any{"text": c.text}
+				if c.token >= 0 {
+					ctr.Params.Arguments[confirmationTokenArgument] = tokens[c.token]
+				}
+				ctx := s.server.WithContext(context.Background(), &streamableSession{id: c.session})
+				result, err := handler(ctx, ctr)
+				if err != nil {
+					t.Fatalf("call %d: error = %v", i, err)
+				}
+				text := resultText(result)
+
+				var planned plan
+				if c.wantPlan {
+					_, body, _ := strings.Cut(text, "\n\n")
+					if err := yaml.Unmarshal([]byte(body), &planned); err != nil || planned.ConfirmationToken == "" {
+						t.Fatalf("call %d: %q is not a plan: %v", i, text, err)
+					}
+					if planned.Tool != "destroy" || planned.Arguments["text"] != c.text {
+						t.Errorf("call %d: plan = %+v", i, planned)
+					}
+				}
+				tokens = append(tokens, planned.ConfirmationToken)
+				if executed != c.wantExecuted {
+					t.Errorf("call %d: executed = %v, want %v", i, executed, c.wantExecuted)
+				}
+				if result.IsError != (c.wantError != "") || !strings.Contains(text, c.wantError) {
+					t.Errorf("call %d: result = %q, want error %q", i, text, c.wantError)
+				}
+			}
+		})
+	}
+}
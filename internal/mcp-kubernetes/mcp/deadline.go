@@ -0,0 +1,213 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fleezesd/mcp-kubernetes/pkg/log"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// timeoutArgument is the argument a client sets to override the timeout of a single call.
+const timeoutArgument = "timeout_seconds"
+
+// cancelledNotification is the method of the notification a client sends to cancel a request.
+const cancelledNotification = "notifications/cancelled"
+
+type callKey struct {
+	sessionID string
+	requestID string
+}
+
+// calls tracks the tool calls in flight so that clients can cancel them.
+type calls struct {
+	// requestIDs maps the context of a tool call being dispatched to its JSON-RPC request ID,
+	// the tool handler only receives the former.
+	requestIDs sync.Map
+
+	mu      sync.Mutex
+	cancels map[callKey]context.CancelCauseFunc
+}
+
+func newCalls() *calls {
+	return &calls{cancels: make(map[callKey]context.CancelCauseFunc)}
+}
+
+func (c *calls) add(key callKey, cancel context.CancelCauseFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancels[key] = cancel
+}
+
+func (c *calls) remove(key callKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cancels, key)
+}
+
+func (c *calls) cancel(key callKey, cause error) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cancel, ok := c.cancels[key]
+	if ok {
+		cancel(cause)
+	}
+	return ok
+}
+
+// beforeCallTool remembers the request ID of a tool call until its handler runs.
+func (s *Server) beforeCallTool(ctx context.Context, id any, message *mcp.CallToolRequest) {
+	s.toolsMu.RLock()
+	_, registered := s.tools[message.Params.Name]
+	s.toolsMu.RUnlock()
+	// Calls of unknown tools never reach the handler that would forget the ID
+	if registered {
+		s.calls.requestIDs.Store(ctx, id)
+	}
+}
+
+// cancelCall aborts the tool call a client cancelled with notifications/cancelled.
+func (s *Server) cancelCall(ctx context.Context, notification mcp.JSONRPCNotification) {
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		s.cancelRequest(ctx, cs.SessionID(), notification.Params.AdditionalFields)
+	}
+}
+
+// cancelRequest aborts the tool call of the session the params of a
+// notifications/cancelled name.
+func (s *Server) cancelRequest(ctx context.Context, sessionID string, params map[string]any) {
+	requestID, ok := params["requestId"]
+	if !ok {
+		return
+	}
+	reason, _ := params["reason"].(string)
+	cause := fmt.Errorf("cancelled by the client")
+	if reason != "" {
+		cause = fmt.Errorf("cancelled by the client: %s", reason)
+	}
+	if s.calls.cancel(callKey{sessionID: sessionID, requestID: fmt.Sprint(requestID)}, cause) {
+		log.C(ctx).Infow("Cancelled tool call", "session", sessionID, "request", requestID, "reason", reason)
+	}
+}
+
+// stdioInput returns the messages read from in without the cancellations, which it
+// handles as soon as they're read. The stdio transport handles messages one at a
+// time, it would only read a cancellation once the call it cancels is over.
+func (s *Server) stdioInput(ctx context.Context, in io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	// Messages wait here while the transport is busy with an earlier one
+	lines := make(chan []byte, 256)
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 && !s.stdioCancel(ctx, line) {
+				lines <- line
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		for line := range lines {
+			if _, err := pw.Write(line); err != nil {
+				break
+			}
+		}
+		// Drain the messages left so that the reader never blocks
+		for range lines {
+		}
+		_ = pw.Close()
+	}()
+	return pr
+}
+
+// stdioCancel handles the line if it's a notifications/cancelled, and reports whether it was.
+func (s *Server) stdioCancel(ctx context.Context, line []byte) bool {
+	var notification mcp.JSONRPCNotification
+	if err := json.Unmarshal(line, &notification); err != nil || notification.Method != cancelledNotification {
+		return false
+	}
+	s.cancelRequest(ctx, stdioSessionID, notification.Params.AdditionalFields)
+	return true
+}
+
+// withDeadline is a tool handler middleware that bounds how long a tool call may
+// run and lets the client cancel it. The timeout of the tool applies unless the call
+// asks for another one, which cannot exceed the maximum.
+func (s *Server) withDeadline(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		requestID, _ := s.calls.requestIDs.LoadAndDelete(ctx)
+
+		timeout, err := s.toolTimeout(ctr)
+		if err != nil {
+			return NewTextResult("", err), nil
+		}
+		if _, ok := ctr.Params.Arguments[timeoutArgument]; ok {
+			arguments := make(map[string]any, len(ctr.Params.Arguments))
+			for key, value := range ctr.Params.Arguments {
+				if key != timeoutArgument {
+					arguments[key] = value
+				}
+			}
+			ctr.Params.Arguments = arguments
+		}
+
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+		if timeout > 0 {
+			var cancelTimeout context.CancelFunc
+			ctx, cancelTimeout = context.WithTimeoutCause(ctx, timeout,
+				fmt.Errorf("%s did not complete within %s", ctr.Params.Name, timeout))
+			defer cancelTimeout()
+		}
+		if cs := server.ClientSessionFromContext(ctx); cs != nil && requestID != nil {
+			key := callKey{sessionID: cs.SessionID(), requestID: fmt.Sprint(requestID)}
+			s.calls.add(key, cancel)
+			defer s.calls.remove(key)
+		}
+
+		result, err := next(ctx, ctr)
+		if cause := context.Cause(ctx); cause != nil {
+			// Whatever the handler returned, the client must learn why the call was aborted
+			return NewTextResult("", fmt.Errorf("%s aborted: %v", ctr.Params.Name, cause)), nil
+		}
+		return result, err
+	}
+}
+
+// toolTimeout returns the timeout of the call, 0 if it's unbounded.
+func (s *Server) toolTimeout(ctr mcp.CallToolRequest) (time.Duration, error) {
+	timeout := s.configuration.ToolTimeout
+	if t, ok := s.configuration.ToolTimeouts[ctr.Params.Name]; ok {
+		timeout = t
+	}
+
+	value, ok := ctr.Params.Arguments[timeoutArgument]
+	if !ok || value == nil {
+		return timeout, nil
+	}
+	seconds, ok := value.(float64)
+	if !ok || seconds <= 0 {
+		return 0, fmt.Errorf("%s must be a positive number of seconds", timeoutArgument)
+	}
+	timeout = time.Duration(seconds * float64(time.Second))
+	if maxTimeout := s.configuration.MaxToolTimeout; maxTimeout > 0 && timeout > maxTimeout {
+		return 0, fmt.Errorf("%s cannot exceed %g seconds", timeoutArgument, maxTimeout.Seconds())
+	}
+	return timeout, nil
+}
+
+// withTimeoutArgument declares the timeout argument on a tool.
+func withTimeoutArgument(tool *mcp.Tool) {
+	mcp.WithNumber(timeoutArgument, mcp.Description("Seconds the call may run before it's aborted. "+
+		"If omitted, the default timeout of the tool is used (Optional)"))(tool)
+}
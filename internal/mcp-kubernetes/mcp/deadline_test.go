@@ -0,0 +1,118 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	waitCall          = `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"wait"}}`
+	cancelWaitMessage = `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":2,"reason":"user abort"}}`
+)
+
+// waitForCall waits until the call of the session is in flight.
+func waitForCall(t *testing.T, s *Server, key callKey) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.calls.mu.Lock()
+		_, ok := s.calls.cancels[key]
+		s.calls.mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("call %+v never started", key)
+}
+
+func TestCancelToolCall(t *testing.T) {
+	// The timeout ends the call if the cancellation is lost
+	configuration := Configuration{ToolTimeout: 10 * time.Second}
+	const wantResult = "wait aborted: cancelled by the client: user abort"
+
+	t.Run("streamable http", func(t *testing.T) {
+		ts, s := newStreamableTestServer(t, configuration)
+		id := openSession(t, ts, "")
+
+		results := make(chan string, 1)
+		go func() {
+			req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(waitCall))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set(sessionIDHeader, id)
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				results <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			data, _ := io.ReadAll(resp.Body)
+			results <- string(data)
+		}()
+		waitForCall(t, s, callKey{sessionID: id, requestID: "2"})
+
+		start := time.Now()
+		if resp := streamableDo(t, ts, http.MethodPost, id, "", "application/json", cancelWaitMessage); resp.status != http.StatusAccepted {
+			t.Fatalf("notifications/cancelled = %d %q", resp.status, resp.body)
+		}
+		result := <-results
+		if !strings.Contains(result, wantResult) || !strings.Contains(result, `"isError":true`) {
+			t.Errorf("tools/call = %s, want %q", result, wantResult)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("the call ended %s after it was cancelled", elapsed)
+		}
+	})
+
+	t.Run("stdio", func(t *testing.T) {
+		s := newTestServer(t, configuration)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stdinReader, stdin := io.Pipe()
+		stdout, stdoutWriter := io.Pipe()
+		done := make(chan error, 1)
+		go func() {
+			done <- server.NewStdioServer(s.server).Listen(ctx, s.stdioInput(ctx, stdinReader), stdoutWriter)
+		}()
+		defer func() {
+			_ = stdin.Close()
+			<-done
+		}()
+		responses := bufio.NewReader(stdout)
+		write := func(message string) {
+			t.Helper()
+			if _, err := io.WriteString(stdin, message+"\n"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		write(initializeRequest)
+		if _, err := responses.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		}
+		write(initializedMessage)
+		write(waitCall)
+		waitForCall(t, s, callKey{sessionID: stdioSessionID, requestID: "2"})
+
+		start := time.Now()
+		write(cancelWaitMessage)
+		result, err := responses.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(result, wantResult) || !strings.Contains(result, `"isError":true`) {
+			t.Errorf("tools/call = %s, want %q", result, wantResult)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("the call ended %s after it was cancelled", elapsed)
+		}
+	})
+}
@@ -0,0 +1,507 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	v1 "github.com/fleezesd/mcp-kubernetes/pkg/api/mcpkubernetes/v1"
+	"github.com/fleezesd/mcp-kubernetes/pkg/log"
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// grpcSessionKey is how we find the session of a gRPC connection in a context.Context.
+type grpcSessionKey struct{}
+
+// grpcSession is the MCP session of a gRPC connection. It's registered with the
+// credentials of the first call made on the connection and lives as long as it.
+type grpcSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	initialized   atomic.Bool
+	requestID     atomic.Int64
+	registerMu    sync.Mutex
+	registered    bool
+	// terminated is set once the session was terminated, the connection can't open another one.
+	terminated bool
+	// streams receive the notifications sent during the StreamTool calls of the
+	// connection, keyed by the progress token of the call.
+	streamsMu sync.Mutex
+	streams   map[string]chan mcp.JSONRPCNotification
+	done      chan struct{}
+}
+
+var _ server.ClientSession = (*grpcSession)(nil)
+
+func (gs *grpcSession) SessionID() string {
+	return gs.id
+}
+
+func (gs *grpcSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return gs.notifications
+}
+
+func (gs *grpcSession) Initialize() {
+	gs.initialized.Store(true)
+}
+
+func (gs *grpcSession) Initialized() bool {
+	return gs.initialized.Load()
+}
+
+// dispatch forwards the notifications of the session to the StreamTool call they
+// belong to, or to all of them if they don't carry a known progress token.
+// Notifications nobody listens to are dropped.
+func (gs *grpcSession) dispatch() {
+	for {
+		select {
+		case notification := <-gs.notifications:
+			gs.streamsMu.Lock()
+			token, _ := notification.Params.AdditionalFields["progressToken"].(string)
+			if stream, ok := gs.streams[token]; ok {
+				select {
+				case stream <- notification:
+				default:
+				}
+			} else {
+				for _, stream := range gs.streams {
+					select {
+					case stream <- notification:
+					default:
+					}
+				}
+			}
+			gs.streamsMu.Unlock()
+		case <-gs.done:
+			return
+		}
+	}
+}
+
+func (gs *grpcSession) subscribe(token string) chan mcp.JSONRPCNotification {
+	stream := make(chan mcp.JSONRPCNotification, 100)
+	gs.streamsMu.Lock()
+	defer gs.streamsMu.Unlock()
+	gs.streams[token] = stream
+	return stream
+}
+
+func (gs *grpcSession) unsubscribe(token string) {
+	gs.streamsMu.Lock()
+	defer gs.streamsMu.Unlock()
+	delete(gs.streams, token)
+}
+
+// grpcSessions is a stats.Handler that ties an MCP session to every gRPC connection.
+type grpcSessions struct {
+	server *server.MCPServer
+}
+
+var _ stats.Handler = (*grpcSessions)(nil)
+
+func (h *grpcSessions) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	session := &grpcSession{
+		id:            uuid.New().String(),
+		notifications: make(chan mcp.JSONRPCNotification, 100),
+		streams:       make(map[string]chan mcp.JSONRPCNotification),
+		done:          make(chan struct{}),
+	}
+	go session.dispatch()
+	return context.WithValue(ctx, grpcSessionKey{}, session)
+}
+
+func (h *grpcSessions) HandleConn(ctx context.Context, connStats stats.ConnStats) {
+	if _, ok := connStats.(*stats.ConnEnd); !ok {
+		return
+	}
+	session, ok := ctx.Value(grpcSessionKey{}).(*grpcSession)
+	if !ok {
+		return
+	}
+	session.registerMu.Lock()
+	defer session.registerMu.Unlock()
+	if session.registered {
+		h.server.UnregisterSession(ctx, session.id)
+		session.registered = false
+	}
+	close(session.done)
+}
+
+func (h *grpcSessions) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (h *grpcSessions) HandleRPC(context.Context, stats.RPCStats) {}
+
+// GRPCServer serves the tools of the MCP server over gRPC. Every call is handled
+// as an MCP message of the session of its connection, so it goes through the same
+// hooks and tool handler middleware as on the other transports.
+type GRPCServer struct {
+	v1.UnimplementedToolServiceServer
+	s *Server
+}
+
+// ServeGRPC returns a gRPC server exposing the ToolService.
+func (s *Server) ServeGRPC() *grpc.Server {
+	options := []grpc.ServerOption{
+		grpc.StatsHandler(&grpcSessions{server: s.server}),
+		grpc.ChainUnaryInterceptor(s.unaryBearerToken),
+		grpc.ChainStreamInterceptor(s.streamBearerToken),
+	}
+	if s.configuration.TLSConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(s.configuration.TLSConfig)))
+	}
+	if s.configuration.GRPC != nil && s.configuration.GRPC.Timeout > 0 {
+		options = append(options, grpc.ConnectionTimeout(s.configuration.GRPC.Timeout))
+	}
+	grpcServer := grpc.NewServer(options...)
+	v1.RegisterToolServiceServer(grpcServer, &GRPCServer{s: s})
+	return grpcServer
+}
+
+// unaryBearerToken authenticates unary calls with the bearer token of their authorization metadata.
+func (s *Server) unaryBearerToken(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := s.grpcAuthenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamBearerToken authenticates streaming calls with the bearer token of their authorization metadata.
+func (s *Server) streamBearerToken(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.grpcAuthenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+func (s *Server) grpcAuthenticate(ctx context.Context) (context.Context, error) {
+	var authorization string
+	if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+		authorization = values[0]
+	}
+	authenticated, err := s.authenticate(ctx, authorization)
+	if errors.Is(err, errMissingBearerToken) {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token in authorization metadata")
+	}
+	if err != nil {
+		log.C(ctx).Infow("Rejected unauthenticated gRPC call", "err", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+	return authenticated, nil
+}
+
+// authenticatedStream is a grpc.ServerStream carrying the context of its authenticated caller.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (as *authenticatedStream) Context() context.Context {
+	return as.ctx
+}
+
+func (g *GRPCServer) ListTools(ctx context.Context, _ *v1.ListToolsRequest) (*v1.ListToolsResponse, error) {
+	session, ctx, err := g.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response := &v1.ListToolsResponse{}
+	var cursor mcp.Cursor
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		result, err := g.handle(ctx, session, mcp.MethodToolsList, params)
+		if err != nil {
+			return nil, err
+		}
+		list, ok := result.(mcp.ListToolsResult)
+		if !ok {
+			return nil, status.Errorf(codes.Internal, "unexpected result %T", result)
+		}
+		for _, tool := range list.Tools {
+			protoTool, err := toProtoTool(tool)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			response.Tools = append(response.Tools, protoTool)
+		}
+		if cursor = list.NextCursor; cursor == "" {
+			return response, nil
+		}
+	}
+}
+
+func (g *GRPCServer) GetTool(ctx context.Context, req *v1.GetToolRequest) (*v1.Tool, error) {
+	response, err := g.ListTools(ctx, &v1.ListToolsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	for _, tool := range response.Tools {
+		if tool.Name == req.GetName() {
+			return tool, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "tool %q not found", req.GetName())
+}
+
+func (g *GRPCServer) CallTool(ctx context.Context, req *v1.CallToolRequest) (*v1.CallToolResponse, error) {
+	session, ctx, err := g.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return g.callTool(ctx, session, req, nil)
+}
+
+func (g *GRPCServer) StreamTool(req *v1.CallToolRequest, stream grpc.ServerStreamingServer[v1.StreamToolResponse]) error {
+	session, ctx, err := g.session(stream.Context())
+	if err != nil {
+		return err
+	}
+	token := uuid.New().String()
+	notifications := session.subscribe(token)
+	defer session.unsubscribe(token)
+
+	type outcome struct {
+		result *v1.CallToolResponse
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := g.callTool(ctx, session, req, map[string]any{"progressToken": token})
+		done <- outcome{result: result, err: err}
+	}()
+
+	send := func(notification mcp.JSONRPCNotification) error {
+		params, err := toStruct(notification.Params)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		return stream.Send(&v1.StreamToolResponse{Event: &v1.StreamToolResponse_Notification{
+			Notification: &v1.Notification{Method: notification.Method, Params: params},
+		}})
+	}
+	for {
+		select {
+		case notification := <-notifications:
+			if err := send(notification); err != nil {
+				return err
+			}
+		case o := <-done:
+			if o.err != nil {
+				return o.err
+			}
+			// Flush the notifications sent right before the result
+			for len(notifications) > 0 {
+				if err := send(<-notifications); err != nil {
+					return err
+				}
+			}
+			return stream.Send(&v1.StreamToolResponse{Event: &v1.StreamToolResponse_Result{Result: o.result}})
+		}
+	}
+}
+
+func (g *GRPCServer) callTool(ctx context.Context, session *grpcSession, req *v1.CallToolRequest, meta map[string]any) (*v1.CallToolResponse, error) {
+	if !g.s.hasTool(req.GetName()) {
+		return nil, status.Errorf(codes.NotFound, "tool %q not found", req.GetName())
+	}
+	params := map[string]any{
+		"name":      req.GetName(),
+		"arguments": req.GetArguments().AsMap(),
+	}
+	if meta != nil {
+		params["_meta"] = meta
+	}
+	result, err := g.handle(ctx, session, mcp.MethodToolsCall, params)
+	if err != nil {
+		return nil, err
+	}
+	callResult, ok := result.(mcp.CallToolResult)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "unexpected result %T", result)
+	}
+	response := &v1.CallToolResponse{IsError: callResult.IsError}
+	for _, content := range callResult.Content {
+		protoContent, err := toProtoContent(content)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		response.Content = append(response.Content, protoContent)
+	}
+	return response, nil
+}
+
+// session returns the session of the connection of ctx, registering and initializing
+// it on the first call, together with ctx bound to the session.
+func (g *GRPCServer) session(ctx context.Context) (*grpcSession, context.Context, error) {
+	session, ok := ctx.Value(grpcSessionKey{}).(*grpcSession)
+	if !ok {
+		return nil, nil, status.Error(codes.Internal, "no MCP session found for the connection")
+	}
+	session.registerMu.Lock()
+	defer session.registerMu.Unlock()
+	if session.terminated {
+		return nil, nil, status.Error(codes.Unavailable, "the session was terminated, open a new connection")
+	}
+	if !session.registered {
+		ctx, release, ok := g.s.reserveSession(ctx)
+		if !ok {
+			return nil, nil, status.Error(codes.ResourceExhausted, "too many sessions")
+		}
+		defer release()
+		ctx = withSessionCloser(ctx, func() { g.terminate(session) })
+		if err := g.s.server.RegisterSession(ctx, session); err != nil {
+			return nil, nil, status.Errorf(codes.Internal, "session registration failed: %v", err)
+		}
+		session.registered = true
+		clientName, clientVersion := grpcClient(ctx)
+		_, err := g.handle(g.s.server.WithContext(ctx, session), session, mcp.MethodInitialize, map[string]any{
+			"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+			"capabilities":    map[string]any{},
+			"clientInfo":      map[string]any{"name": clientName, "version": clientVersion},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		session.Initialize()
+	} else if err := g.s.checkSessionToken(ctx, session.id); err != nil {
+		return nil, nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return session, g.s.server.WithContext(ctx, session), nil
+}
+
+// grpcClient returns the name and version of the client of ctx from the first product
+// of its user agent, grpc if it has none.
+func grpcClient(ctx context.Context) (name, version string) {
+	if userAgent := metadata.ValueFromIncomingContext(ctx, "user-agent"); len(userAgent) > 0 {
+		if fields := strings.Fields(userAgent[0]); len(fields) > 0 {
+			name, version, _ = strings.Cut(fields[0], "/")
+			return name, version
+		}
+	}
+	return "grpc", ""
+}
+
+// terminate unregisters the session and fails the later calls of its connection.
+func (g *GRPCServer) terminate(session *grpcSession) {
+	session.registerMu.Lock()
+	defer session.registerMu.Unlock()
+	if session.registered {
+		g.s.server.UnregisterSession(context.Background(), session.id)
+		session.registered = false
+	}
+	session.terminated = true
+}
+
+// handle sends a JSON-RPC request for method to the MCP server and returns its result.
+func (g *GRPCServer) handle(ctx context.Context, session *grpcSession, method mcp.MCPMethod, params any) (any, error) {
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      session.requestID.Add(1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	switch response := g.s.server.HandleMessage(ctx, message).(type) {
+	case mcp.JSONRPCResponse:
+		return response.Result, nil
+	case mcp.JSONRPCError:
+		code := codes.Internal
+		switch response.Error.Code {
+		case mcp.INVALID_REQUEST, mcp.INVALID_PARAMS:
+			code = codes.InvalidArgument
+		case mcp.METHOD_NOT_FOUND:
+			code = codes.Unimplemented
+		}
+		return nil, status.Error(code, response.Error.Message)
+	default:
+		return nil, status.Errorf(codes.Internal, "unexpected response %T", response)
+	}
+}
+
+// hasTool reports whether the tool name is registered.
+func (s *Server) hasTool(name string) bool {
+	s.toolsMu.RLock()
+	defer s.toolsMu.RUnlock()
+	_, ok := s.tools[name]
+	return ok
+}
+
+func toProtoTool(tool mcp.Tool) (*v1.Tool, error) {
+	data, err := json.Marshal(tool)
+	if err != nil {
+		return nil, err
+	}
+	var marshaled struct {
+		InputSchema map[string]any `json:"inputSchema"`
+	}
+	if err := json.Unmarshal(data, &marshaled); err != nil {
+		return nil, err
+	}
+	inputSchema, err := structpb.NewStruct(marshaled.InputSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the input schema of tool %s: %w", tool.Name, err)
+	}
+	return &v1.Tool{
+		Name:        tool.Name,
+		Description: tool.Description,
+		InputSchema: inputSchema,
+		Annotations: &v1.ToolAnnotations{
+			Title:       tool.Annotations.Title,
+			ReadOnly:    tool.Annotations.ReadOnlyHint,
+			Destructive: tool.Annotations.DestructiveHint,
+			Idempotent:  tool.Annotations.IdempotentHint,
+			OpenWorld:   tool.Annotations.OpenWorldHint,
+		},
+	}, nil
+}
+
+func toProtoContent(content mcp.Content) (*v1.Content, error) {
+	switch c := content.(type) {
+	case mcp.TextContent:
+		return &v1.Content{Type: c.Type, Text: c.Text}, nil
+	case mcp.ImageContent:
+		return &v1.Content{Type: c.Type, Data: c.Data, MimeType: c.MIMEType}, nil
+	case mcp.EmbeddedResource:
+		resource, err := toStruct(c.Resource)
+		if err != nil {
+			return nil, err
+		}
+		return &v1.Content{Type: c.Type, Resource: resource}, nil
+	default:
+		return nil, fmt.Errorf("unsupported content %T", content)
+	}
+}
+
+// toStruct converts v to a protobuf Struct through its JSON representation.
+func toStruct(v any) (*structpb.Struct, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(fields)
+}
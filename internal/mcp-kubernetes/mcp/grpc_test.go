@@ -0,0 +1,53 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/fleezesd/mcp-kubernetes/pkg/api/mcpkubernetes/v1"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGRPCSessionClient(t *testing.T) {
+	tests := []struct {
+		name        string
+		userAgent   []string
+		wantName    string
+		wantVersion string
+	}{
+		{name: "no user agent", wantName: "grpc"},
+		{name: "empty user agent", userAgent: []string{""}, wantName: "grpc"},
+		{name: "whitespace-only user agent", userAgent: []string{" \t "}, wantName: "grpc"},
+		{name: "product and version", userAgent: []string{"mcp-client/1.2.3 grpc-go/1.71.0"}, wantName: "mcp-client", wantVersion: "1.2.3"},
+		{name: "product without version", userAgent: []string{"  mcp-client  "}, wantName: "mcp-client"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, Configuration{})
+			g := &GRPCServer{s: s}
+			session := &grpcSession{
+				id:            tt.name,
+				notifications: make(chan mcp.JSONRPCNotification, 100),
+				streams:       make(map[string]chan mcp.JSONRPCNotification),
+				done:          make(chan struct{}),
+			}
+			defer close(session.done)
+			go session.dispatch()
+
+			md := metadata.MD{}
+			if tt.userAgent != nil {
+				md.Set("user-agent", tt.userAgent...)
+			}
+			ctx := context.WithValue(metadata.NewIncomingContext(context.Background(), md), grpcSessionKey{}, session)
+			if _, err := g.ListTools(ctx, &v1.ListToolsRequest{}); err != nil {
+				t.Fatalf("ListTools() error = %v", err)
+			}
+			defer g.terminate(session)
+
+			if name, version := s.sessions.client(session.id); name != tt.wantName || version != tt.wantVersion {
+				t.Errorf("client = %q %q, want %q %q", name, version, tt.wantName, tt.wantVersion)
+			}
+		})
+	}
+}
@@ -2,40 +2,173 @@ package mcp
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path"
 	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/audit"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/authn"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/policy"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/registry"
 	"github.com/fleezesd/mcp-kubernetes/pkg/kubernetes"
 	"github.com/fleezesd/mcp-kubernetes/pkg/log"
+	genericoptions "github.com/fleezesd/mcp-kubernetes/pkg/options"
 	"github.com/fleezesd/mcp-kubernetes/pkg/version"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
-	genericapiserver "k8s.io/apiserver/pkg/server"
+	"golang.org/x/sync/errgroup"
 )
 
 type Server struct {
-	configuration *configuration
+	configuration *Configuration
 	server        *server.MCPServer
+	sessions      *sessions
+	confirmations *confirmations
+	limits        *limits
+	calls         *calls
+	policies      atomic.Pointer[policy.Engine]
+	audit         *audit.Logger
+	authenticator authn.Authenticator
+	registry      *registry.Registry
+	toolsMu       sync.RWMutex
+	tools         map[string]mcp.ToolAnnotation
 	k             *kubernetes.Kubernetes
 }
 
-type configuration struct {
-	kubeConfig string
+type Configuration struct {
+	KubeConfig string
+	// TokenPassthrough makes every SSE, HTTP or gRPC session authenticate against the
+	// Kubernetes API server with the bearer token of its own connection.
+	TokenPassthrough bool
+	// RevealCredentials disables the redaction of credentials in configuration views.
+	RevealCredentials bool
+	// ReadOnly restricts the registered tools to those that don't modify the cluster.
+	ReadOnly bool
+	// EnabledTools are glob patterns of the tool names to register, all tools if empty.
+	EnabledTools []string
+	// DisabledTools are glob patterns of the tool names that must never be registered.
+	DisabledTools []string
+	// AllowedNamespaces are glob patterns of the namespaces tools may reach, all if empty.
+	AllowedNamespaces []string
+	// DeniedNamespaces are glob patterns of the namespaces tools must never reach.
+	DeniedNamespaces []string
+	// ConfirmDestructive requires destructive tool calls to be planned and then
+	// confirmed with the token returned by the plan.
+	ConfirmDestructive bool
+	// ConfirmationTTL is how long a confirmation token remains valid.
+	ConfirmationTTL time.Duration
+	// Policies are evaluated before every tool call to allow, deny or require
+	// the confirmation of the call.
+	Policies []policy.Rule
+	// Audit configures where every tool call is recorded.
+	Audit *audit.Options
+	// SessionRate and SessionBurst bound the tool calls per second of every session, unlimited if 0.
+	SessionRate  float64
+	SessionBurst int
+	// ToolRate and ToolBurst bound the calls per second of every tool, unlimited if 0.
+	ToolRate  float64
+	ToolBurst int
+	// MaxConcurrentCalls bounds the tool calls running at the same time, unlimited if 0.
+	MaxConcurrentCalls int
+	// KubeAPIQPS and KubeAPIBurst bound the requests sent to the Kubernetes API server,
+	// the client-go defaults are used if 0.
+	KubeAPIQPS   float32
+	KubeAPIBurst int
+	// ToolTimeout bounds how long a tool call may run, unbounded if 0.
+	ToolTimeout time.Duration
+	// ToolTimeouts override ToolTimeout for the tools they name.
+	ToolTimeouts map[string]time.Duration
+	// MaxToolTimeout bounds the timeout a call may ask for, unbounded if 0.
+	MaxToolTimeout time.Duration
+	// TLSConfig makes the network transports serve TLS, plaintext if nil.
+	TLSConfig *tls.Config
+	// Authn configures how clients of the network transports authenticate.
+	Authn *authn.Options
+	// HTTP configures the listener and the timeouts of the SSE and HTTP transports.
+	// They listen on the loopback interface if its address is empty.
+	HTTP *genericoptions.HTTPOptions
+	// AllowedOrigins are glob patterns of the origins browsers may reach the SSE and
+	// HTTP transports from, loopback origins if empty.
+	AllowedOrigins []string
+	// AllowedHosts are glob patterns of the Host headers the SSE and HTTP transports
+	// accept. If empty, loopback listeners only accept loopback host names.
+	AllowedHosts []string
+	// HTTPBasePath prefixes the paths of the SSE and Streamable HTTP endpoints, e.g. /mcp/k8s.
+	// The health endpoint isn't prefixed.
+	HTTPBasePath string
+	// CORSMaxAge is how long browsers may cache the answer to a CORS preflight request.
+	CORSMaxAge time.Duration
+	// GRPC configures the listener of the gRPC transport.
+	GRPC *genericoptions.GRPCOptions
+	// Registry configures the registration of the SSE and HTTP listeners in the
+	// Consul agent of Consul.
+	Registry *registry.Options
+	Consul   *genericoptions.ConsulOptions
+	// MaxSessions bounds the sessions open at the same time, unlimited if 0.
+	MaxSessions int
+	// SessionIdleTimeout is how long a session of a network transport may go without
+	// sending a request before it's terminated, forever if 0.
+	SessionIdleTimeout time.Duration
+	// SessionAdmins are glob patterns of the authenticated usernames allowed to list and
+	// terminate the sessions. The session tools are only registered if it's not empty.
+	SessionAdmins []string
 }
 
-func NewServer(kubeConfig string) (*Server, error) {
+func NewServer(configuration Configuration) (*Server, error) {
 	s := &Server{
-		configuration: &configuration{
-			kubeConfig: kubeConfig,
-		},
-		server: server.NewMCPServer(
-			"mcp-kubernetes",
-			version.Get().String(),
-			server.WithResourceCapabilities(true, true),
-			server.WithPromptCapabilities(true),
-			server.WithToolCapabilities(true),
-			server.WithLogging(),
-		),
+		configuration: &configuration,
+		sessions:      newSessions(),
+		confirmations: newConfirmations(configuration.ConfirmationTTL),
+		limits:        newLimits(&configuration),
+		calls:         newCalls(),
+	}
+	auditLogger, err := audit.New(configuration.Audit)
+	if err != nil {
+		return nil, err
+	}
+	s.audit = auditLogger
+	authenticator, err := authn.New(configuration.Authn)
+	if err != nil {
+		return nil, err
+	}
+	s.authenticator = authenticator
+	if s.registry, err = registry.New(configuration.Registry, configuration.Consul); err != nil {
+		return nil, err
+	}
+	hooks := &server.Hooks{}
+	hooks.AddBeforeAny(s.sessionActivity)
+	hooks.AddOnRegisterSession(s.registerSession)
+	hooks.AddAfterInitialize(s.initializeSession)
+	hooks.AddBeforeCallTool(s.beforeCallTool)
+	hooks.AddOnUnregisterSession(s.unregisterSession)
+	s.server = server.NewMCPServer(
+		"mcp-kubernetes",
+		version.Get().String(),
+		server.WithResourceCapabilities(true, true),
+		server.WithPromptCapabilities(true),
+		server.WithToolCapabilities(true),
+		server.WithLogging(),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(s.withDeadline),
+		server.WithToolHandlerMiddleware(s.withSessionNamespace),
+		server.WithToolHandlerMiddleware(s.withAudit),
+		server.WithToolHandlerMiddleware(s.withSessionIdentity),
+		server.WithToolHandlerMiddleware(s.withRateLimit),
+		server.WithToolHandlerMiddleware(s.withPolicy),
+		server.WithToolHandlerMiddleware(s.withConfirmation),
+	)
+	s.server.AddNotificationHandler(cancelledNotification, s.cancelCall)
+	if err := s.SetPolicies(configuration.Policies); err != nil {
+		return nil, fmt.Errorf("failed to load policies: %w", err)
 	}
 	if err := s.reloadKubernetesClient(); err != nil {
 		return nil, err
@@ -45,46 +178,341 @@ func NewServer(kubeConfig string) (*Server, error) {
 }
 
 func (s *Server) reloadKubernetesClient() error {
-	k, err := kubernetes.NewKubernetes(s.configuration.kubeConfig)
+	k, err := kubernetes.NewKubernetes(s.configuration.KubeConfig,
+		kubernetes.WithNamespaceGuard(s.configuration.AllowedNamespaces, s.configuration.DeniedNamespaces),
+		kubernetes.WithRateLimits(s.configuration.KubeAPIQPS, s.configuration.KubeAPIBurst),
+	)
 	if err != nil {
 		return err
 	}
 	s.k = k
-	s.server.SetTools(slices.Concat(
+	s.sessions.resetClients()
+	tools := slices.Concat(
 		s.initConfiguration(),
 		s.initNamespace(),
-	)...)
+		s.initAuth(),
+		s.initAudit(),
+		s.initSessions(),
+	)
+	tools = slices.DeleteFunc(tools, func(tool server.ServerTool) bool {
+		if s.configuration.ReadOnly && !tool.Tool.Annotations.ReadOnlyHint {
+			return true
+		}
+		return !s.isToolEnabled(tool.Tool.Name)
+	})
+	annotations := make(map[string]mcp.ToolAnnotation, len(tools))
+	for i := range tools {
+		annotations[tools[i].Tool.Name] = tools[i].Tool.Annotations
+		withTimeoutArgument(&tools[i].Tool)
+		if s.configuration.ConfirmDestructive && !tools[i].Tool.Annotations.ReadOnlyHint && tools[i].Tool.Annotations.DestructiveHint {
+			withConfirmationTokenArgument(&tools[i].Tool)
+		}
+	}
+	s.toolsMu.Lock()
+	s.tools = annotations
+	s.toolsMu.Unlock()
+	s.server.SetTools(tools...)
+	if err := s.registry.SetTags(context.Background(), s.registryTags()); err != nil {
+		log.Errorw(err, "Failed to update the tags of the registered listeners")
+	}
 	return nil
 }
 
-func (s *Server) ServeSse(baseUrl string) *server.SSEServer {
+// registryTags returns the tags of the cluster and the context the server is connected to.
+func (s *Server) registryTags() []string {
+	if s.k.IsInCluster() {
+		return []string{"cluster=in-cluster"}
+	}
+	kubeContext, cluster := s.k.CurrentContext()
+	tags := make([]string, 0, 2)
+	if cluster != "" {
+		tags = append(tags, "cluster="+cluster)
+	}
+	if kubeContext != "" {
+		tags = append(tags, "context="+kubeContext)
+	}
+	return tags
+}
+
+// toolAnnotations returns the annotations of the registered tool name.
+func (s *Server) toolAnnotations(name string) mcp.ToolAnnotation {
+	s.toolsMu.RLock()
+	defer s.toolsMu.RUnlock()
+	return s.tools[name]
+}
+
+// isToolEnabled reports whether the tool name matches the enabled tools, if any,
+// and none of the disabled tools.
+func (s *Server) isToolEnabled(name string) bool {
+	matches := func(patterns []string) bool {
+		return slices.ContainsFunc(patterns, func(pattern string) bool {
+			matched, _ := path.Match(pattern, name)
+			return matched
+		})
+	}
+	if len(s.configuration.EnabledTools) > 0 && !matches(s.configuration.EnabledTools) {
+		return false
+	}
+	return !matches(s.configuration.DisabledTools)
+}
+
+func (s *Server) ServeSse(baseUrl string, httpServer *http.Server) *server.SSEServer {
 	options := make([]server.SSEOption, 0)
 	if baseUrl != "" {
 		options = append(options, server.WithBaseURL(baseUrl))
 	}
+	if s.configuration.HTTPBasePath != "" {
+		options = append(options, server.WithBasePath(s.configuration.HTTPBasePath))
+	}
+	if httpServer != nil {
+		options = append(options, server.WithHTTPServer(httpServer))
+	}
 	return server.NewSSEServer(s.server, options...)
 }
 
-func (s *Server) Run(SSEBaseURL string, SSEPort int, stopCh <-chan struct{}) {
-	log.Infow("Starting mcp kubernetes server")
-	if SSEPort > 0 {
-		sseServer := s.ServeSse(SSEBaseURL)
-		defer func() { _ = sseServer.Shutdown(genericapiserver.SetupSignalContext()) }()
-		log.Infow("SSE server starting", "port", SSEPort)
-		if err := sseServer.Start(fmt.Sprintf(":%d", SSEPort)); err != nil {
-			log.Errorw(err, "Failed to start SSE server")
-			return
+// Transports the server can be reached through.
+const (
+	TransportStdio = "stdio"
+	TransportSSE   = "sse"
+	TransportHTTP  = "http"
+	TransportGRPC  = "grpc"
+)
+
+// shutdownTimeout bounds how long the network transports wait for requests in flight to complete.
+const shutdownTimeout = 10 * time.Second
+
+// Run serves the given transports until stopCh is closed, the stdio client goes away
+// or a transport fails, then shuts all of them down. The SSE and Streamable HTTP
+// transports share a server when they're configured with the same address.
+func (s *Server) Run(transports []string, SSEBaseURL string, SSEPort int, HTTPPort int, stopCh <-chan struct{}) error {
+	defer s.Stop()
+	log.Infow("Starting mcp kubernetes server", "transports", transports)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	g, ctx := errgroup.WithContext(ctx)
+	listeners := make(map[int]*transportListener)
+	listener := func(port int) *transportListener {
+		if s.configuration.HTTP != nil && s.configuration.HTTP.Addr != "" {
+			// A configured address serves every network transport
+			port = 0
+		}
+		if l, ok := listeners[port]; ok {
+			return l
 		}
+		l := &transportListener{
+			port:   port,
+			mux:    http.NewServeMux(),
+			server: &http.Server{TLSConfig: s.configuration.TLSConfig},
+		}
+		l.server.Handler = l.mux
+		listeners[port] = l
+		return l
+	}
+	origins := s.newOriginPolicy(SSEBaseURL)
+	if slices.Contains(transports, TransportSSE) {
+		l := listener(SSEPort)
+		sseServer := s.ServeSse(SSEBaseURL, l.server)
+		l.mux.Handle("/", origins.handler(s.withBearerToken(s.withSessionLimit(sseServer.CompleteSsePath(),
+			s.withSessionToken(sseServer.CompleteMessagePath(), sseServer)))))
+		l.names = append(l.names, "SSE")
+		l.transports = append(l.transports, TransportSSE)
+		l.shutdown = append(l.shutdown, sseServer.Shutdown)
 	}
-	if err := server.ServeStdio(s.server); err != nil && !errors.Is(err, context.Canceled) {
-		panic(err)
+	if slices.Contains(transports, TransportHTTP) {
+		l := listener(HTTPPort)
+		streamableServer := s.ServeStreamableHTTP()
+		l.mux.Handle(s.configuration.HTTPBasePath+streamableHTTPEndpoint, origins.handler(s.withBearerToken(streamableServer)))
+		l.names = append(l.names, "Streamable HTTP")
+		l.transports = append(l.transports, TransportHTTP)
+		// Close the notification streams first, the HTTP server waits for them otherwise
+		l.shutdown = append([]func(context.Context) error{streamableServer.Shutdown}, l.shutdown...)
 	}
-	<-stopCh
-	s.Stop()
+	var instances []registry.Instance
+	for _, l := range listeners {
+		l.mux.HandleFunc(healthPath, healthz)
+		listener, err := s.listen(l.server, l.port, strings.Join(l.names, " and "))
+		if err != nil {
+			closeListeners(listeners)
+			return fmt.Errorf("%s server failed: %w", strings.Join(l.names, " and "), err)
+		}
+		l.listener = listener
+		if addr, ok := listener.Addr().(*net.TCPAddr); ok {
+			instances = append(instances, registry.Instance{
+				Host:        addr.IP.String(),
+				Port:        addr.Port,
+				Transports:  l.transports,
+				HealthPath:  healthPath,
+				BasePath:    s.configuration.HTTPBasePath,
+				TLS:         l.server.TLSConfig != nil,
+				ClientCerts: l.server.TLSConfig != nil && l.server.TLSConfig.ClientAuth == tls.RequireAndVerifyClientCert,
+			})
+		}
+	}
+	if err := s.registry.Register(ctx, instances, s.registryTags()); err != nil {
+		closeListeners(listeners)
+		return err
+	}
+	for _, l := range listeners {
+		// Clients must stop finding the server before it stops accepting them
+		l.shutdown = append([]func(context.Context) error{s.registry.Deregister}, l.shutdown...)
+		l.shutdown = append(l.shutdown, l.server.Shutdown)
+		g.Go(func() error {
+			if err := s.serve(l.server, l.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("%s server failed: %w", strings.Join(l.names, " and "), err)
+			}
+			return nil
+		})
+		g.Go(func() error {
+			<-ctx.Done()
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer shutdownCancel()
+			var errs []error
+			for _, shutdown := range l.shutdown {
+				errs = append(errs, shutdown(shutdownCtx))
+			}
+			return errors.Join(errs...)
+		})
+	}
+	if slices.Contains(transports, TransportGRPC) {
+		grpcServer := s.ServeGRPC()
+		g.Go(func() error {
+			listener, err := net.Listen(s.configuration.GRPC.Network, s.configuration.GRPC.Addr)
+			if err != nil {
+				return fmt.Errorf("gRPC server failed: %w", err)
+			}
+			log.Infow("gRPC server starting", "network", s.configuration.GRPC.Network, "addr", s.configuration.GRPC.Addr,
+				"tls", s.configuration.TLSConfig != nil)
+			if err := grpcServer.Serve(listener); err != nil {
+				return fmt.Errorf("gRPC server failed: %w", err)
+			}
+			return nil
+		})
+		g.Go(func() error {
+			<-ctx.Done()
+			stopped := make(chan struct{})
+			go func() {
+				grpcServer.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-time.After(shutdownTimeout):
+				grpcServer.Stop()
+			}
+			return nil
+		})
+	}
+	if s.configuration.SessionIdleTimeout > 0 {
+		g.Go(func() error {
+			s.expireIdleSessions(ctx)
+			return nil
+		})
+	}
+	if slices.Contains(transports, TransportStdio) {
+		g.Go(func() error {
+			stdioServer := server.NewStdioServer(s.server)
+			err := stdioServer.Listen(ctx, s.stdioInput(ctx, os.Stdin), os.Stdout)
+			// The client closed stdin, nobody is left to serve
+			cancel()
+			if err != nil && !errors.Is(err, context.Canceled) {
+				return fmt.Errorf("stdio server failed: %w", err)
+			}
+			return nil
+		})
+	}
+
+	err := g.Wait()
+	log.Infow("Stopped mcp kubernetes server")
+	return err
+}
+
+// transportListener is a network listener serving one or more transports.
+type transportListener struct {
+	port       int
+	names      []string
+	transports []string
+	mux        *http.ServeMux
+	server     *http.Server
+	listener   net.Listener
+	shutdown   []func(context.Context) error
+}
+
+func closeListeners(listeners map[int]*transportListener) {
+	for _, l := range listeners {
+		if l.listener != nil {
+			_ = l.listener.Close()
+		}
+	}
+}
+
+// listen opens the configured listener of httpServer, or port of the loopback
+// interface if none is configured.
+func (s *Server) listen(httpServer *http.Server, port int, transport string) (net.Listener, error) {
+	listenerOptions := s.configuration.HTTP
+	if listenerOptions == nil || listenerOptions.Addr == "" {
+		listenerOptions = &genericoptions.HTTPOptions{Network: "tcp", Addr: fmt.Sprintf("127.0.0.1:%d", port)}
+		if s.configuration.HTTP != nil {
+			listenerOptions.ReadTimeout = s.configuration.HTTP.ReadTimeout
+			listenerOptions.WriteTimeout = s.configuration.HTTP.WriteTimeout
+			listenerOptions.IdleTimeout = s.configuration.HTTP.IdleTimeout
+		}
+	}
+	listenerOptions.ApplyTo(httpServer)
+	httpServer.Handler = withStreamWriteDeadline(httpServer.Handler)
+
+	listener, err := listenerOptions.Listen()
+	if err != nil {
+		return nil, err
+	}
+	log.Infow(transport+" server starting", "network", listenerOptions.Network, "addr", listenerOptions.Addr,
+		"tls", httpServer.TLSConfig != nil)
+	return listener, nil
+}
+
+// serve serves httpServer on listener. It serves TLS when the server has a TLS
+// configuration, plain HTTP otherwise.
+func (s *Server) serve(httpServer *http.Server, listener net.Listener) error {
+	if httpServer.TLSConfig != nil {
+		// The certificates come from the TLS configuration, which reloads them
+		return httpServer.ServeTLS(listener, "", "")
+	}
+	return httpServer.Serve(listener)
+}
+
+// healthPath is the path of the health endpoint of the SSE and HTTP listeners.
+const healthPath = "/healthz"
+
+func healthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(`{"status": "ok"}`))
+}
+
+// withStreamWriteDeadline lifts the write timeout of GET requests, which both
+// transports answer with a long-lived event stream.
+func withStreamWriteDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 func (s *Server) Stop() {
 	if s.k != nil {
 		s.k.Close()
 	}
+	if err := s.audit.Close(); err != nil {
+		log.Errorw(err, "Failed to close audit log")
+	}
 }
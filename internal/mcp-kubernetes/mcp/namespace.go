@@ -3,26 +3,89 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func (s *Server) initNamespace() []server.ServerTool {
 	tools := []server.ServerTool{
 		{
 			Tool: mcp.NewTool("namespace_list",
-				mcp.WithDescription("List all the kubernetes namespaces in the current cluster")),
+				mcp.WithDescription("List all the kubernetes namespaces in the current cluster"),
+				mcp.WithToolAnnotation(mcp.ToolAnnotation{
+					Title:           "Namespaces: List",
+					ReadOnlyHint:    true,
+					DestructiveHint: false,
+					IdempotentHint:  true,
+					OpenWorldHint:   true,
+				})),
 			Handler: s.namespacesList,
 		},
+		{
+			Tool: mcp.NewTool("namespace_set_default",
+				mcp.WithDescription("Set the default namespace used by every namespaced tool of the current session "+
+					"when no namespace is provided"),
+				mcp.WithString("namespace", mcp.Description("Namespace to use by default. "+
+					"If omitted or empty, the namespace of the current kubeconfig context is used again. "+
+					"(Optional)")),
+				mcp.WithToolAnnotation(mcp.ToolAnnotation{
+					Title:           "Namespaces: Set Default",
+					ReadOnlyHint:    true,
+					DestructiveHint: false,
+					IdempotentHint:  true,
+					OpenWorldHint:   false,
+				})),
+			Handler: s.namespaceSetDefault,
+		},
 	}
 	return tools
 }
 
 func (s *Server) namespacesList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	result, err := s.k.NamespacesList(ctx)
+	k, err := s.kubernetes(ctx)
+	if err != nil {
+		return NewTextResult("", err), nil
+	}
+	result, err := k.NamespacesList(ctx)
 	if err != nil {
 		err = fmt.Errorf("failed to list namespaces: %v", err)
 	}
 	return NewTextResult(result, err), nil
 }
+
+func (s *Server) namespaceSetDefault(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	namespace, _ := ctr.Params.Arguments["namespace"].(string)
+	namespace = strings.TrimSpace(namespace)
+	if namespace != "" {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return NewTextResult("", fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(errs, ", "))), nil
+		}
+	}
+
+	k, err := s.kubernetes(ctx)
+	if err != nil {
+		return NewTextResult("", err), nil
+	}
+	if namespace != "" {
+		if err := k.CheckNamespace(namespace); err != nil {
+			return NewTextResult("", err), nil
+		}
+	}
+
+	cs := server.ClientSessionFromContext(ctx)
+	if cs == nil {
+		return NewTextResult("", fmt.Errorf("no MCP session found for the request")), nil
+	}
+	if err := s.sessions.setNamespace(cs.SessionID(), namespace); err != nil {
+		return NewTextResult("", fmt.Errorf("failed to set default namespace: %v", err)), nil
+	}
+
+	if namespace == "" {
+		// ctx still carries the previous default of the session
+		return NewTextResult(fmt.Sprintf("Default namespace reset to the kubeconfig namespace %q", k.DefaultNamespace(context.Background())), nil), nil
+	}
+	return NewTextResult(fmt.Sprintf("Default namespace set to %q", namespace), nil), nil
+}
@@ -0,0 +1,127 @@
+package mcp
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fleezesd/mcp-kubernetes/pkg/log"
+)
+
+// loopbackHosts are the host names a loopback listener is reached with.
+var loopbackHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// originPolicy validates the Host and Origin headers of the requests of the SSE
+// and HTTP transports, which protects local servers against DNS rebinding, and
+// answers the CORS preflight requests of the allowed origins.
+type originPolicy struct {
+	// allowedOrigins are glob patterns of the allowed origins, loopback origins if empty.
+	allowedOrigins []string
+	// allowedHosts are glob patterns of the allowed host names, any if empty.
+	allowedHosts []string
+	maxAge       time.Duration
+}
+
+// newOriginPolicy returns the policy of the configured origins and hosts. Unless
+// hosts are configured, listeners on the loopback interface only accept the loopback
+// host names and the one of baseURL, while other listeners accept any host.
+func (s *Server) newOriginPolicy(baseURL string) *originPolicy {
+	p := &originPolicy{
+		allowedOrigins: s.configuration.AllowedOrigins,
+		allowedHosts:   s.configuration.AllowedHosts,
+		maxAge:         s.configuration.CORSMaxAge,
+	}
+	if len(p.allowedHosts) == 0 && s.listensOnLoopback() {
+		p.allowedHosts = slices.Clone(loopbackHosts)
+		if u, err := url.Parse(baseURL); err == nil && u.Hostname() != "" {
+			p.allowedHosts = append(p.allowedHosts, u.Hostname())
+		}
+	}
+	return p
+}
+
+// listensOnLoopback reports whether the SSE and HTTP listeners are bound to the loopback interface.
+func (s *Server) listensOnLoopback() bool {
+	if s.configuration.HTTP == nil || s.configuration.HTTP.Addr == "" {
+		return true
+	}
+	if s.configuration.HTTP.Network == "unix" {
+		// Browsers can't reach unix sockets
+		return false
+	}
+	host, _, err := net.SplitHostPort(s.configuration.HTTP.Addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (p *originPolicy) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !p.allowsHost(r.Host) {
+			log.C(r.Context()).Infow("Rejected request to a host that is not allowed", "host", r.Host, "remote", r.RemoteAddr)
+			http.Error(w, "Host not allowed", http.StatusForbidden)
+			return
+		}
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			// Not a cross-origin browser request
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !p.allowsOrigin(origin) {
+			log.C(r.Context()).Infow("Rejected request from an origin that is not allowed", "origin", origin, "remote", r.RemoteAddr)
+			http.Error(w, "Origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+		w.Header().Set("Access-Control-Expose-Headers", sessionIDHeader+", WWW-Authenticate")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Accept, Last-Event-ID, "+sessionIDHeader)
+			if p.maxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.maxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (p *originPolicy) allowsHost(host string) bool {
+	if len(p.allowedHosts) == 0 {
+		return true
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.Trim(host, "[]")
+	return matchesAny(p.allowedHosts, strings.ToLower(host))
+}
+
+func (p *originPolicy) allowsOrigin(origin string) bool {
+	if len(p.allowedOrigins) > 0 {
+		return matchesAny(p.allowedOrigins, origin)
+	}
+	u, err := url.Parse(origin)
+	return err == nil && slices.Contains(loopbackHosts, u.Hostname())
+}
+
+func matchesAny(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	})
+}
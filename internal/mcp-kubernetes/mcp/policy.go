@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/authn"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/policy"
+	"github.com/fleezesd/mcp-kubernetes/pkg/log"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// confirmationRequiredKey is how we find whether a policy requires the call in a
+// context.Context to be confirmed.
+type confirmationRequiredKey struct{}
+
+func confirmationRequired(ctx context.Context) bool {
+	required, _ := ctx.Value(confirmationRequiredKey{}).(bool)
+	return required
+}
+
+// SetPolicies compiles rules and makes them apply to every subsequent tool call.
+// The previous policies are kept if rules don't compile.
+func (s *Server) SetPolicies(rules []policy.Rule) error {
+	engine, err := policy.New(rules)
+	if err != nil {
+		return err
+	}
+	s.policies.Store(engine)
+	return nil
+}
+
+// withPolicy is a tool handler middleware that evaluates the policies against every
+// tool call before it reaches the cluster.
+func (s *Server) withPolicy(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		engine := s.policies.Load()
+		if engine.Empty() {
+			return next(ctx, ctr)
+		}
+
+		decision := engine.Evaluate(ctx, s.policyInput(ctx, ctr, engine))
+		switch decision.Action {
+		case policy.ActionDeny:
+			log.C(ctx).Infow("Tool call denied by policy", "tool", ctr.Params.Name, "policy", decision.Rule)
+			return NewTextResult("", errors.New("denied by policy: "+decision.Message)), nil
+		case policy.ActionConfirm:
+			ctx = context.WithValue(ctx, confirmationRequiredKey{}, true)
+		}
+		return next(ctx, ctr)
+	}
+}
+
+// policyInput gathers what the policies of engine are evaluated against. Details that
+// can't be resolved are left empty so that policies decide how to treat them, those
+// that take API requests are only resolved when a policy reads them.
+func (s *Server) policyInput(ctx context.Context, ctr mcp.CallToolRequest, engine *policy.Engine) *policy.Input {
+	in := &policy.Input{
+		Tool:      ctr.Params.Name,
+		Arguments: make(map[string]any, len(ctr.Params.Arguments)),
+	}
+	for key, value := range ctr.Params.Arguments {
+		if key != confirmationTokenArgument {
+			in.Arguments[key] = value
+		}
+	}
+
+	apiVersion, _ := ctr.Params.Arguments["apiVersion"].(string)
+	kind, _ := ctr.Params.Arguments["kind"].(string)
+	if gv, err := schema.ParseGroupVersion(apiVersion); err == nil {
+		in.Group, in.Version = gv.Group, gv.Version
+	}
+	in.Kind = kind
+
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		in.SessionID = cs.SessionID()
+	}
+	if identity := authn.IdentityFrom(ctx); identity != nil {
+		in.PrincipalUsername, in.PrincipalGroups = identity.Username, identity.Groups
+	}
+
+	k, err := s.kubernetes(ctx)
+	if err != nil {
+		return in
+	}
+	in.Namespace, _ = ctr.Params.Arguments["namespace"].(string)
+	if in.Namespace == "" {
+		in.Namespace = k.DefaultNamespace(ctx)
+	}
+	if in.Namespace != "" && engine.NeedsNamespaceLabels() {
+		if labels, err := k.NamespaceLabels(ctx, in.Namespace); err == nil {
+			in.NamespaceLabels = labels
+		}
+	}
+	if !engine.NeedsUser() {
+		return in
+	}
+	if userInfo, err := s.userInfo(ctx); err == nil {
+		in.Username, in.Groups = userInfo.Username, userInfo.Groups
+	}
+	return in
+}
+
+// objectArgument returns the object passed to a write tool either as a map or as YAML/JSON.
+func objectArgument(argument any) map[string]any {
+	switch t := argument.(type) {
+	case map[string]any:
+		return t
+	case string:
+		obj := map[string]any{}
+		if err := yaml.Unmarshal([]byte(t), &obj); err != nil {
+			return nil
+		}
+		return obj
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/time/rate"
+)
+
+// limits holds the token buckets and the concurrency cap applied to tool calls.
+type limits struct {
+	sessionRate  rate.Limit
+	sessionBurst int
+	toolRate     rate.Limit
+	toolBurst    int
+	// inFlight has a slot per tool call that may run concurrently, nil if unbounded.
+	inFlight chan struct{}
+
+	mu       sync.Mutex
+	sessions map[string]*rate.Limiter
+	tools    map[string]*rate.Limiter
+}
+
+func newLimits(configuration *Configuration) *limits {
+	l := &limits{
+		sessionRate:  rate.Limit(configuration.SessionRate),
+		sessionBurst: configuration.SessionBurst,
+		toolRate:     rate.Limit(configuration.ToolRate),
+		toolBurst:    configuration.ToolBurst,
+		sessions:     make(map[string]*rate.Limiter),
+		tools:        make(map[string]*rate.Limiter),
+	}
+	if configuration.MaxConcurrentCalls > 0 {
+		l.inFlight = make(chan struct{}, configuration.MaxConcurrentCalls)
+	}
+	return l
+}
+
+// limiter returns the token bucket of key in buckets, creating it if needed.
+// It returns nil if r is unlimited.
+func (l *limits) limiter(buckets map[string]*rate.Limiter, key string, r rate.Limit, burst int) *rate.Limiter {
+	if r <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := buckets[key]
+	if !ok {
+		limiter = rate.NewLimiter(r, max(burst, 1))
+		buckets[key] = limiter
+	}
+	return limiter
+}
+
+func (l *limits) forgetSession(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.sessions, id)
+}
+
+// withRateLimit is a tool handler middleware that rejects tool calls exceeding the
+// rate of their session or tool, or the number of calls allowed to run concurrently.
+// Rejected calls fail right away instead of waiting, so that a client looping on
+// tool calls gets pushed back.
+func (s *Server) withRateLimit(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if cs := server.ClientSessionFromContext(ctx); cs != nil {
+			limiter := s.limits.limiter(s.limits.sessions, cs.SessionID(), s.limits.sessionRate, s.limits.sessionBurst)
+			if limiter != nil && !limiter.Allow() {
+				return NewTextResult("", fmt.Errorf("rate limit exceeded for this session, at most %g tool calls per second "+
+					"are allowed, retry later", float64(s.limits.sessionRate))), nil
+			}
+		}
+		limiter := s.limits.limiter(s.limits.tools, ctr.Params.Name, s.limits.toolRate, s.limits.toolBurst)
+		if limiter != nil && !limiter.Allow() {
+			return NewTextResult("", fmt.Errorf("rate limit exceeded for tool %s, at most %g calls per second "+
+				"are allowed, retry later", ctr.Params.Name, float64(s.limits.toolRate))), nil
+		}
+
+		if s.limits.inFlight != nil {
+			select {
+			case s.limits.inFlight <- struct{}{}:
+				defer func() { <-s.limits.inFlight }()
+			default:
+				return NewTextResult("", fmt.Errorf("too many tool calls in flight, at most %d may run concurrently, "+
+					"retry later", cap(s.limits.inFlight))), nil
+			}
+		}
+		return next(ctx, ctr)
+	}
+}
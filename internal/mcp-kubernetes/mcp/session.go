@@ -0,0 +1,496 @@
+package mcp
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/authn"
+	"github.com/fleezesd/mcp-kubernetes/pkg/kubernetes"
+	"github.com/fleezesd/mcp-kubernetes/pkg/log"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+// bearerTokenKey is how we find the bearer token of a connection in a context.Context.
+type bearerTokenKey struct{}
+
+// sessionCloserKey is how we find the function terminating a session being registered in a context.Context.
+type sessionCloserKey struct{}
+
+// withSessionCloser makes closer terminate the session registered with ctx.
+func withSessionCloser(ctx context.Context, closer func()) context.Context {
+	return context.WithValue(ctx, sessionCloserKey{}, closer)
+}
+
+// sessionReservationKey is how we find the slot reserved for a session being registered in a context.Context.
+type sessionReservationKey struct{}
+
+// stdioSessionID is the ID of the session of the stdio transport.
+const stdioSessionID = "stdio"
+
+// session holds the state kept for a single connected MCP client.
+type session struct {
+	// token is the bearer token presented when the session was opened.
+	token string
+	// identity is who authenticated when the session was opened, nil without authentication.
+	identity *authn.Identity
+	// k is the Kubernetes client authenticated with token, built lazily.
+	k *kubernetes.Kubernetes
+	// namespace is the default namespace chosen by the client for this session.
+	namespace string
+	// userInfo is what the API server knows about the user of the session, resolved lazily.
+	userInfo *authenticationv1.UserInfo
+	// clientName and clientVersion are reported by the client when it initializes the session.
+	clientName    string
+	clientVersion string
+	// transport is the transport the session was opened on.
+	transport string
+	// kubeContext is the kubeconfig context in use when the session was opened.
+	kubeContext string
+	// startedAt and lastActive are when the session was opened and last sent a request.
+	startedAt  time.Time
+	lastActive time.Time
+	// calls counts the tool calls of the session.
+	calls int
+	// close terminates the session on its transport, nil if it can't be terminated.
+	close func()
+}
+
+type sessions struct {
+	mu    sync.Mutex
+	items map[string]*session
+	// reserved counts the slots reserved for network sessions not registered yet.
+	reserved int
+}
+
+// reservation holds a slot for a session of a network transport until the session is
+// registered or the reservation released.
+type reservation struct {
+	ss   *sessions
+	held bool
+}
+
+// reserve reserves a slot for another session of a network transport, nil if the max
+// sessions, counting the reserved ones, are already open. max <= 0 means no limit.
+func (ss *sessions) reserve(max int) *reservation {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if max > 0 && ss.networkCount()+ss.reserved >= max {
+		return nil
+	}
+	ss.reserved++
+	return &reservation{ss: ss, held: true}
+}
+
+// release gives the slot back unless the session was registered. It's safe to call more than once.
+func (r *reservation) release() {
+	r.ss.mu.Lock()
+	defer r.ss.mu.Unlock()
+	r.releaseLocked()
+}
+
+func (r *reservation) releaseLocked() {
+	if r.held {
+		r.held = false
+		r.ss.reserved--
+	}
+}
+
+func newSessions() *sessions {
+	return &sessions{items: make(map[string]*session)}
+}
+
+// add adds the session, which takes the slot of r if any.
+func (ss *sessions) add(id string, sess *session, r *reservation) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if r != nil {
+		r.releaseLocked()
+	}
+	ss.items[id] = sess
+}
+
+func (ss *sessions) remove(id string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.items, id)
+}
+
+// networkCount returns the number of sessions of the network transports. ss.mu must be held.
+func (ss *sessions) networkCount() int {
+	count := 0
+	for _, sess := range ss.items {
+		if sess.transport != TransportStdio {
+			count++
+		}
+	}
+	return count
+}
+
+// touch records that the session sent a request for method.
+func (ss *sessions) touch(id string, method mcp.MCPMethod) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if sess, ok := ss.items[id]; ok {
+		sess.lastActive = time.Now()
+		if method == mcp.MethodToolsCall {
+			sess.calls++
+		}
+	}
+}
+
+// idle returns the functions terminating the sessions inactive since before.
+func (ss *sessions) idle(before time.Time) map[string]func() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	closers := make(map[string]func())
+	for id, sess := range ss.items {
+		if sess.close != nil && sess.lastActive.Before(before) {
+			closers[id] = sess.close
+		}
+	}
+	return closers
+}
+
+func (ss *sessions) get(id string) (*session, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	sess, ok := ss.items[id]
+	return sess, ok
+}
+
+func (ss *sessions) namespace(id string) string {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if sess, ok := ss.items[id]; ok {
+		return sess.namespace
+	}
+	return ""
+}
+
+func (ss *sessions) setNamespace(id, namespace string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	sess, ok := ss.items[id]
+	if !ok {
+		return fmt.Errorf("session %s not found", id)
+	}
+	sess.namespace = namespace
+	return nil
+}
+
+func (ss *sessions) client(id string) (name, version string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if sess, ok := ss.items[id]; ok {
+		return sess.clientName, sess.clientVersion
+	}
+	return "", ""
+}
+
+// resetClients drops the cached per-session clients so they are rebuilt from
+// the current kubeconfig on the next tool call.
+func (ss *sessions) resetClients() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for _, sess := range ss.items {
+		sess.k = nil
+		sess.userInfo = nil
+	}
+}
+
+// errMissingBearerToken is returned when a request without a bearer token must be rejected.
+var errMissingBearerToken = errors.New("missing bearer token")
+
+// authenticate stores the bearer token of the authorization value in ctx. When
+// authentication is configured, the token must be accepted by an authenticator and
+// the identity it belongs to is stored in ctx too. When authentication or token
+// passthrough is enabled, requests without a bearer token are rejected.
+func (s *Server) authenticate(ctx context.Context, authorization string) (context.Context, error) {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	token = strings.TrimSpace(token)
+	if (s.configuration.TokenPassthrough || s.authenticator != nil) && (!ok || token == "") {
+		return nil, errMissingBearerToken
+	}
+	if s.authenticator != nil {
+		identity, err := s.authenticator.Authenticate(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		ctx = authn.WithIdentity(ctx, identity)
+	}
+	if ok && token != "" {
+		ctx = context.WithValue(ctx, bearerTokenKey{}, token)
+	}
+	return ctx, nil
+}
+
+// withBearerToken authenticates every request with the bearer token of its
+// Authorization header.
+func (s *Server) withBearerToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := s.authenticate(r.Context(), r.Header.Get("Authorization"))
+		if errors.Is(err, errMissingBearerToken) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Missing bearer token in Authorization header", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			log.C(r.Context()).Infow("Rejected unauthenticated request", "remote", r.RemoteAddr, "err", err)
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "Invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// errSessionTokenMismatch is returned when a request of a session doesn't present the
+// bearer token the session was opened with.
+var errSessionTokenMismatch = errors.New("the bearer token is not the one the session was opened with")
+
+// checkSessionToken makes sure that, with token passthrough, the request of ctx presents
+// the bearer token of session id, whose credentials its tool calls are made with.
+// Unknown sessions are left to their transport to reject.
+func (s *Server) checkSessionToken(ctx context.Context, id string) error {
+	if !s.configuration.TokenPassthrough {
+		return nil
+	}
+	sess, ok := s.sessions.get(id)
+	if !ok {
+		return nil
+	}
+	if sess.token == "" || subtle.ConstantTimeCompare([]byte(sess.token), []byte(bearerTokenFromContext(ctx))) != 1 {
+		return errSessionTokenMismatch
+	}
+	return nil
+}
+
+// withSessionToken rejects the SSE messages of a session that don't present its bearer token.
+func (s *Server) withSessionToken(messagePath string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == messagePath {
+			if err := s.checkSessionToken(r.Context(), r.URL.Query().Get("sessionId")); err != nil {
+				writeInvalidSessionToken(w, r, err)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeInvalidSessionToken(w http.ResponseWriter, r *http.Request, err error) {
+	log.C(r.Context()).Infow("Rejected request with another bearer token than its session", "remote", r.RemoteAddr, "err", err)
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	http.Error(w, "Invalid bearer token for the session", http.StatusUnauthorized)
+}
+
+func bearerTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(bearerTokenKey{}).(string)
+	return token
+}
+
+// reserveSession reserves a slot for another session of a network transport, so that
+// concurrent requests can't open more than the max sessions. It returns ctx carrying the
+// reservation, which registering the session with it takes, and the function releasing
+// it if the session isn't registered. ok is false if the max sessions are open.
+func (s *Server) reserveSession(ctx context.Context) (_ context.Context, release func(), ok bool) {
+	r := s.sessions.reserve(s.configuration.MaxSessions)
+	if r == nil {
+		return ctx, nil, false
+	}
+	return context.WithValue(ctx, sessionReservationKey{}, r), r.release, true
+}
+
+func (s *Server) registerSession(ctx context.Context, cs server.ClientSession) {
+	now := time.Now()
+	sess := &session{
+		token:      bearerTokenFromContext(ctx),
+		identity:   authn.IdentityFrom(ctx),
+		transport:  TransportSSE,
+		startedAt:  now,
+		lastActive: now,
+	}
+	switch cs.(type) {
+	case *streamableSession:
+		sess.transport = TransportHTTP
+	case *grpcSession:
+		sess.transport = TransportGRPC
+	}
+	if cs.SessionID() == stdioSessionID {
+		sess.transport = TransportStdio
+	}
+	if s.k != nil {
+		sess.kubeContext, _ = s.k.CurrentContext()
+	}
+	sess.close, _ = ctx.Value(sessionCloserKey{}).(func())
+	r, _ := ctx.Value(sessionReservationKey{}).(*reservation)
+	s.sessions.add(cs.SessionID(), sess, r)
+}
+
+// withSessionLimit rejects the SSE streams opening a session above the maximum number
+// of sessions, and makes the sessions of the others terminable by ending their stream.
+func (s *Server) withSessionLimit(ssePath string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != ssePath {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, release, ok := s.reserveSession(r.Context())
+		if !ok {
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "Too many sessions", http.StatusServiceUnavailable)
+			return
+		}
+		defer release()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(withSessionCloser(ctx, cancel)))
+	})
+}
+
+// sessionActivity is a hook recording the requests of every session.
+func (s *Server) sessionActivity(ctx context.Context, id any, method mcp.MCPMethod, message any) {
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		s.sessions.touch(cs.SessionID(), method)
+	}
+}
+
+// expireIdleSessions terminates the sessions that sent no request for the idle timeout until ctx is done.
+func (s *Server) expireIdleSessions(ctx context.Context) {
+	timeout := s.configuration.SessionIdleTimeout
+	ticker := time.NewTicker(min(max(timeout/4, time.Second), time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for id, closeSession := range s.sessions.idle(time.Now().Add(-timeout)) {
+				log.Infow("Terminating idle session", "session", id, "idleTimeout", timeout)
+				closeSession()
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Server) initializeSession(ctx context.Context, id any, ir *mcp.InitializeRequest, result *mcp.InitializeResult) {
+	cs := server.ClientSessionFromContext(ctx)
+	if cs == nil {
+		return
+	}
+	s.sessions.mu.Lock()
+	defer s.sessions.mu.Unlock()
+	if sess, ok := s.sessions.items[cs.SessionID()]; ok {
+		sess.clientName = ir.Params.ClientInfo.Name
+		sess.clientVersion = ir.Params.ClientInfo.Version
+	}
+}
+
+func (s *Server) unregisterSession(ctx context.Context, cs server.ClientSession) {
+	s.sessions.remove(cs.SessionID())
+	s.limits.forgetSession(cs.SessionID())
+}
+
+// withSessionIdentity is a tool handler middleware that rejects tool calls
+// authenticated as someone else than the user who opened the session.
+func (s *Server) withSessionIdentity(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if s.authenticator == nil {
+			return next(ctx, ctr)
+		}
+		cs := server.ClientSessionFromContext(ctx)
+		if cs == nil {
+			return NewTextResult("", fmt.Errorf("no MCP session found for the request")), nil
+		}
+		sess, ok := s.sessions.get(cs.SessionID())
+		identity := authn.IdentityFrom(ctx)
+		if !ok || sess.identity == nil || identity == nil || sess.identity.Username != identity.Username {
+			return NewTextResult("", fmt.Errorf("the request is not authenticated as the user of session %s", cs.SessionID())), nil
+		}
+		return next(ctx, ctr)
+	}
+}
+
+// withSessionNamespace is a tool handler middleware that makes the default namespace
+// chosen for the calling session the fallback of every namespaced request.
+func (s *Server) withSessionNamespace(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if cs := server.ClientSessionFromContext(ctx); cs != nil {
+			if namespace := s.sessions.namespace(cs.SessionID()); namespace != "" {
+				ctx = kubernetes.WithNamespace(ctx, namespace)
+			}
+		}
+		return next(ctx, ctr)
+	}
+}
+
+// kubernetes returns the Kubernetes client that tool calls in ctx must use.
+// Without token passthrough every session shares the server's client.
+func (s *Server) kubernetes(ctx context.Context) (*kubernetes.Kubernetes, error) {
+	if !s.configuration.TokenPassthrough {
+		return s.k, nil
+	}
+
+	cs := server.ClientSessionFromContext(ctx)
+	if cs == nil {
+		return nil, fmt.Errorf("no MCP session found for the request")
+	}
+	sess, ok := s.sessions.get(cs.SessionID())
+	if !ok || sess.token == "" {
+		return nil, fmt.Errorf("session %s has no bearer token", cs.SessionID())
+	}
+	if err := s.checkSessionToken(ctx, cs.SessionID()); err != nil {
+		return nil, err
+	}
+
+	s.sessions.mu.Lock()
+	defer s.sessions.mu.Unlock()
+	if sess.k == nil {
+		k, err := s.k.Derived(sess.token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes client for session: %w", err)
+		}
+		log.Debugw("Created kubernetes client for session", "session", cs.SessionID())
+		sess.k = k
+	}
+	return sess.k, nil
+}
+
+// userInfo returns the user the API server sees for the session of ctx.
+func (s *Server) userInfo(ctx context.Context) (*authenticationv1.UserInfo, error) {
+	var sess *session
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		sess, _ = s.sessions.get(cs.SessionID())
+	}
+	if sess != nil {
+		s.sessions.mu.Lock()
+		userInfo := sess.userInfo
+		s.sessions.mu.Unlock()
+		if userInfo != nil {
+			return userInfo, nil
+		}
+	}
+
+	k, err := s.kubernetes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userInfo, err := k.UserInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sess != nil {
+		s.sessions.mu.Lock()
+		sess.userInfo = userInfo
+		s.sessions.mu.Unlock()
+	}
+	return userInfo, nil
+}
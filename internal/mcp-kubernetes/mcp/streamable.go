@@ -0,0 +1,307 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// streamableHTTPEndpoint is the path the Streamable HTTP transport is served on.
+const streamableHTTPEndpoint = "/mcp"
+
+// sessionIDHeader is the header carrying the session of a Streamable HTTP request.
+const sessionIDHeader = "Mcp-Session-Id"
+
+// maxRequestBodySize bounds the size of the messages a client may POST.
+const maxRequestBodySize = 4 << 20
+
+// streamableSession is a client session of the Streamable HTTP transport.
+type streamableSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	initialized   atomic.Bool
+	// streaming is set while the client listens for notifications with a GET request.
+	streaming atomic.Bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var _ server.ClientSession = (*streamableSession)(nil)
+
+func (ss *streamableSession) SessionID() string {
+	return ss.id
+}
+
+func (ss *streamableSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return ss.notifications
+}
+
+func (ss *streamableSession) Initialize() {
+	ss.initialized.Store(true)
+}
+
+func (ss *streamableSession) Initialized() bool {
+	return ss.initialized.Load()
+}
+
+func (ss *streamableSession) close() {
+	ss.closeOnce.Do(func() { close(ss.done) })
+}
+
+// StreamableHTTPServer serves the MCP Streamable HTTP transport on a single endpoint.
+// Clients POST JSON-RPC messages and get the responses as JSON, GET an SSE stream of
+// the notifications of their session, including those sent while their requests are
+// handled, and DELETE their session once done. Sessions are identified by the Mcp-Session-Id header.
+type StreamableHTTPServer struct {
+	server   *server.MCPServer
+	sessions sync.Map
+	// reserveSession reserves a slot for another session, see Server.reserveSession.
+	reserveSession func(ctx context.Context) (context.Context, func(), bool)
+	// checkSessionToken fails if the request of ctx must not be handled by session id.
+	checkSessionToken func(ctx context.Context, id string) error
+}
+
+func (s *Server) ServeStreamableHTTP() *StreamableHTTPServer {
+	return &StreamableHTTPServer{server: s.server, reserveSession: s.reserveSession, checkSessionToken: s.checkSessionToken}
+}
+
+func (s *StreamableHTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.handlePost(w, r)
+	case http.MethodGet:
+		s.handleGet(w, r)
+	case http.MethodDelete:
+		s.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Shutdown terminates every session.
+func (s *StreamableHTTPServer) Shutdown(ctx context.Context) error {
+	s.sessions.Range(func(key, value any) bool {
+		s.terminate(ctx, value.(*streamableSession))
+		return true
+	})
+	return nil
+}
+
+func (s *StreamableHTTPServer) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, fmt.Sprintf("Request body larger than %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	messages, batch, err := splitMessages(body)
+	if err != nil {
+		writeJSONRPCError(w, http.StatusBadRequest, mcp.PARSE_ERROR, "Parse error")
+		return
+	}
+
+	var session *streamableSession
+	if isInitialize(messages) {
+		if len(messages) > 1 {
+			writeJSONRPCError(w, http.StatusBadRequest, mcp.INVALID_REQUEST, "initialize must not be sent in a batch")
+			return
+		}
+		ctx, release, ok := s.reserveSession(r.Context())
+		if !ok {
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "Too many sessions", http.StatusServiceUnavailable)
+			return
+		}
+		defer release()
+		session = &streamableSession{
+			id:            uuid.New().String(),
+			notifications: make(chan mcp.JSONRPCNotification, 100),
+			done:          make(chan struct{}),
+		}
+		// Sessions outlive the request opening them
+		ctx = withSessionCloser(ctx, func() { s.terminate(context.Background(), session) })
+		if err := s.server.RegisterSession(ctx, session); err != nil {
+			http.Error(w, fmt.Sprintf("Session registration failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+		s.sessions.Store(session.id, session)
+	} else if session = s.session(w, r); session == nil {
+		return
+	}
+
+	ctx := s.server.WithContext(r.Context(), session)
+	responses := make([]mcp.JSONRPCMessage, 0, len(messages))
+	for _, message := range messages {
+		if response := s.server.HandleMessage(ctx, message); response != nil {
+			responses = append(responses, response)
+		}
+	}
+
+	w.Header().Set(sessionIDHeader, session.id)
+	if len(responses) == 0 {
+		// Only notifications and responses were sent
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// Responses are always JSON, the notifications sent while the messages were
+	// handled went to the GET stream of the session
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if batch {
+		_ = json.NewEncoder(w).Encode(responses)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(responses[0])
+}
+
+func (s *StreamableHTTPServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "Not acceptable, the endpoint only streams text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	session := s.session(w, r)
+	if session == nil {
+		return
+	}
+	if _, ok := w.(http.Flusher); !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	if !session.streaming.CompareAndSwap(false, true) {
+		http.Error(w, "The session already has a notification stream", http.StatusConflict)
+		return
+	}
+	defer session.streaming.Store(false)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set(sessionIDHeader, session.id)
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	for {
+		select {
+		case notification := <-session.notifications:
+			if err := writeEvent(w, notification); err != nil {
+				return
+			}
+		case <-session.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *StreamableHTTPServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	session := s.session(w, r)
+	if session == nil {
+		return
+	}
+	s.terminate(r.Context(), session)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// session returns the session of the request, or writes the error and returns nil.
+func (s *StreamableHTTPServer) session(w http.ResponseWriter, r *http.Request) *streamableSession {
+	id := r.Header.Get(sessionIDHeader)
+	if id == "" {
+		http.Error(w, "Missing "+sessionIDHeader+" header", http.StatusBadRequest)
+		return nil
+	}
+	session, ok := s.sessions.Load(id)
+	if !ok {
+		// Clients must start a new session when they get a 404
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return nil
+	}
+	if err := s.checkSessionToken(r.Context(), id); err != nil {
+		writeInvalidSessionToken(w, r, err)
+		return nil
+	}
+	return session.(*streamableSession)
+}
+
+func (s *StreamableHTTPServer) terminate(ctx context.Context, session *streamableSession) {
+	if _, loaded := s.sessions.LoadAndDelete(session.id); !loaded {
+		return
+	}
+	session.close()
+	s.server.UnregisterSession(ctx, session.id)
+}
+
+// splitMessages splits a request body into its JSON-RPC messages and reports whether it was a batch.
+func splitMessages(body []byte) ([]json.RawMessage, bool, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var messages []json.RawMessage
+		if err := json.Unmarshal(body, &messages); err != nil {
+			return nil, true, err
+		}
+		if len(messages) == 0 {
+			return nil, true, fmt.Errorf("empty batch")
+		}
+		return messages, true, nil
+	}
+	var message json.RawMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, false, err
+	}
+	return []json.RawMessage{message}, false, nil
+}
+
+func isInitialize(messages []json.RawMessage) bool {
+	for _, message := range messages {
+		var base struct {
+			Method string `json:"method"`
+		}
+		if json.Unmarshal(message, &base) == nil && base.Method == string(mcp.MethodInitialize) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeEvent(w http.ResponseWriter, message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+func writeJSONRPCError(w http.ResponseWriter, status int, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(mcp.JSONRPCError{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Error: struct {
+			Code    int         `json:"code"`
+			Message string      `json:"message"`
+			Data    interface{} `json:"data,omitempty"`
+		}{Code: code, Message: message},
+	})
+}
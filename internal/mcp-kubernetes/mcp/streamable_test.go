@@ -0,0 +1,417 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	initializeRequest  = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0"}}}`
+	initializedMessage = `{"jsonrpc":"2.0","method":"notifications/initialized"}`
+)
+
+// newTestServer returns a server with an echo, a notify and a wait tool, and the session
+// and deadline hooks of Server, without a Kubernetes client.
+func newTestServer(t *testing.T, configuration Configuration) *Server {
+	t.Helper()
+	s := &Server{
+		configuration: &configuration,
+		sessions:      newSessions(),
+		limits:        newLimits(&configuration),
+		calls:         newCalls(),
+		tools:         map[string]mcp.ToolAnnotation{"echo": {}, "notify": {}, "wait": {}},
+	}
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(s.registerSession)
+	hooks.AddAfterInitialize(s.initializeSession)
+	hooks.AddOnUnregisterSession(s.unregisterSession)
+	hooks.AddBeforeCallTool(s.beforeCallTool)
+	s.server = server.NewMCPServer("test", "1.0",
+		server.WithToolCapabilities(false),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(s.withDeadline),
+	)
+	s.server.AddNotificationHandler(cancelledNotification, s.cancelCall)
+	s.server.AddTool(mcp.NewTool("echo", mcp.WithString("text")), func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		text, _ := ctr.Params.Arguments["text"].(string)
+		return NewTextResult(text, nil), nil
+	})
+	s.server.AddTool(mcp.NewTool("notify"), func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		err := s.server.SendNotificationToClient(ctx, "notifications/message", map[string]any{"level": "info", "data": "hello"})
+		return NewTextResult("sent", err), nil
+	})
+	// wait runs until the call is aborted
+	s.server.AddTool(mcp.NewTool("wait"), func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		return NewTextResult("", ctx.Err()), nil
+	})
+	return s
+}
+
+// newStreamableTestServer serves the Streamable HTTP transport of a test server.
+func newStreamableTestServer(t *testing.T, configuration Configuration) (*httptest.Server, *Server) {
+	t.Helper()
+	s := newTestServer(t, configuration)
+	streamable := s.ServeStreamableHTTP()
+	ts := httptest.NewServer(s.withBearerToken(streamable))
+	t.Cleanup(func() {
+		_ = streamable.Shutdown(context.Background())
+		ts.Close()
+	})
+	return ts, s
+}
+
+type streamableResponse struct {
+	status    int
+	sessionID string
+	header    http.Header
+	body      string
+}
+
+func streamableDo(t *testing.T, ts *httptest.Server, method, sessionID, token, accept, body string) streamableResponse {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+	if sessionID != "" {
+		req.Header.Set(sessionIDHeader, sessionID)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return streamableResponse{status: resp.StatusCode, sessionID: resp.Header.Get(sessionIDHeader), header: resp.Header, body: string(data)}
+}
+
+// openSession initializes a session and returns its ID.
+func openSession(t *testing.T, ts *httptest.Server, token string) string {
+	t.Helper()
+	resp := streamableDo(t, ts, http.MethodPost, "", token, "application/json, text/event-stream", initializeRequest)
+	if resp.status != http.StatusOK || resp.sessionID == "" {
+		t.Fatalf("initialize = %d %q, session %q", resp.status, resp.body, resp.sessionID)
+	}
+	if resp := streamableDo(t, ts, http.MethodPost, resp.sessionID, token, "application/json", initializedMessage); resp.status != http.StatusAccepted {
+		t.Fatalf("notifications/initialized = %d %q", resp.status, resp.body)
+	}
+	return resp.sessionID
+}
+
+func TestStreamableHTTPSessionLifecycle(t *testing.T) {
+	ts, s := newStreamableTestServer(t, Configuration{})
+	id := openSession(t, ts, "")
+	if _, ok := s.sessions.get(id); !ok {
+		t.Fatalf("session %s was not registered", id)
+	}
+
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hello"}}}`
+	tests := []struct {
+		name        string
+		method      string
+		sessionID   string
+		accept      string
+		body        string
+		wantStatus  int
+		wantContent string
+		wantBody    string
+	}{
+		{
+			name:        "json response",
+			method:      http.MethodPost,
+			sessionID:   id,
+			accept:      "application/json, text/event-stream",
+			body:        call,
+			wantStatus:  http.StatusOK,
+			wantContent: "application/json",
+			wantBody:    `"text":"hello"`,
+		},
+		{
+			name:        "json response to an event stream client",
+			method:      http.MethodPost,
+			sessionID:   id,
+			accept:      "text/event-stream",
+			body:        call,
+			wantStatus:  http.StatusOK,
+			wantContent: "application/json",
+			wantBody:    `"text":"hello"`,
+		},
+		{
+			name:       "body too large",
+			method:     http.MethodPost,
+			sessionID:  id,
+			accept:     "application/json",
+			body:       call + strings.Repeat(" ", maxRequestBodySize),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "missing session",
+			method:     http.MethodPost,
+			accept:     "application/json",
+			body:       call,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown session",
+			method:     http.MethodPost,
+			sessionID:  "unknown",
+			accept:     "application/json",
+			body:       call,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "stream without accepting event streams",
+			method:     http.MethodGet,
+			sessionID:  id,
+			accept:     "application/json",
+			wantStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:       "unsupported method",
+			method:     http.MethodPut,
+			sessionID:  id,
+			accept:     "application/json",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "delete",
+			method:     http.MethodDelete,
+			sessionID:  id,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "call after delete",
+			method:     http.MethodPost,
+			sessionID:  id,
+			accept:     "application/json",
+			body:       call,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "delete again",
+			method:     http.MethodDelete,
+			sessionID:  id,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := streamableDo(t, ts, tt.method, tt.sessionID, "", tt.accept, tt.body)
+			if resp.status != tt.wantStatus {
+				t.Fatalf("status = %d %q, want %d", resp.status, resp.body, tt.wantStatus)
+			}
+			if got := resp.header.Get("Content-Type"); tt.wantContent != "" && got != tt.wantContent {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContent)
+			}
+			if !strings.Contains(resp.body, tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", resp.body, tt.wantBody)
+			}
+		})
+	}
+
+	if _, ok := s.sessions.get(id); ok {
+		t.Errorf("session %s is still registered after its deletion", id)
+	}
+}
+
+func TestStreamableHTTPBatch(t *testing.T) {
+	ts, _ := newStreamableTestServer(t, Configuration{})
+	id := openSession(t, ts, "")
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		// wantIDs are the IDs of the responses of a batch, in order
+		wantIDs []float64
+		// wantError is the JSON-RPC error code of a rejected body
+		wantError int
+	}{
+		{
+			name: "requests",
+			body: `[{"jsonrpc":"2.0","id":1,"method":"ping"},` +
+				`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}]`,
+			wantStatus: http.StatusOK,
+			wantIDs:    []float64{1, 2},
+		},
+		{
+			name:       "request and notification",
+			body:       `[{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":3,"method":"ping"}]`,
+			wantStatus: http.StatusOK,
+			wantIDs:    []float64{3},
+		},
+		{
+			name:       "single request batch",
+			body:       `[{"jsonrpc":"2.0","id":4,"method":"tools/list"}]`,
+			wantStatus: http.StatusOK,
+			wantIDs:    []float64{4},
+		},
+		{
+			name:       "notifications only",
+			body:       `[{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","method":"notifications/initialized"}]`,
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "initialize in a batch",
+			body:       `[` + initializeRequest + `,{"jsonrpc":"2.0","id":2,"method":"ping"}]`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  mcp.INVALID_REQUEST,
+		},
+		{
+			name:       "empty batch",
+			body:       `[]`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  mcp.PARSE_ERROR,
+		},
+		{
+			name:       "malformed batch",
+			body:       `[{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  mcp.PARSE_ERROR,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := streamableDo(t, ts, http.MethodPost, id, "", "application/json, text/event-stream", tt.body)
+			if resp.status != tt.wantStatus {
+				t.Fatalf("status = %d %q, want %d", resp.status, resp.body, tt.wantStatus)
+			}
+			if tt.wantError != 0 {
+				var rpcError mcp.JSONRPCError
+				if err := json.Unmarshal([]byte(resp.body), &rpcError); err != nil || rpcError.Error.Code != tt.wantError {
+					t.Errorf("body = %q, want error code %d", resp.body, tt.wantError)
+				}
+				return
+			}
+			if tt.wantIDs == nil {
+				return
+			}
+			var responses []struct {
+				ID    float64         `json:"id"`
+				Error json.RawMessage `json:"error"`
+			}
+			if err := json.Unmarshal([]byte(resp.body), &responses); err != nil {
+				t.Fatalf("body = %q is not a batch: %v", resp.body, err)
+			}
+			if len(responses) != len(tt.wantIDs) {
+				t.Fatalf("got %d responses %q, want %d", len(responses), resp.body, len(tt.wantIDs))
+			}
+			for i, response := range responses {
+				if response.ID != tt.wantIDs[i] || response.Error != nil {
+					t.Errorf("response %d = %+v, want a result with id %v", i, response, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStreamableHTTPNotificationStream(t *testing.T) {
+	ts, _ := newStreamableTestServer(t, Configuration{})
+	id := openSession(t, ts, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(sessionIDHeader, id)
+	stream, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Body.Close()
+	if stream.StatusCode != http.StatusOK {
+		t.Fatalf("GET status = %d, want %d", stream.StatusCode, http.StatusOK)
+	}
+
+	if resp := streamableDo(t, ts, http.MethodGet, id, "", "text/event-stream", ""); resp.status != http.StatusConflict {
+		t.Errorf("second GET status = %d, want %d", resp.status, http.StatusConflict)
+	}
+
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"notify"}}`
+	if resp := streamableDo(t, ts, http.MethodPost, id, "", "application/json", call); resp.status != http.StatusOK {
+		t.Fatalf("tools/call = %d %q", resp.status, resp.body)
+	}
+	scanner := bufio.NewScanner(stream.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			if !strings.Contains(data, `"method":"notifications/message"`) {
+				t.Errorf("notification = %s, want notifications/message", data)
+			}
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting the session ends its stream
+	if resp := streamableDo(t, ts, http.MethodDelete, id, "", "", ""); resp.status != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d", resp.status)
+	}
+	if _, err := io.Copy(io.Discard, stream.Body); err != nil && !errors.Is(err, context.Canceled) {
+		t.Errorf("stream ended with %v", err)
+	}
+}
+
+func TestStreamableHTTPSessionLimit(t *testing.T) {
+	ts, _ := newStreamableTestServer(t, Configuration{MaxSessions: 2})
+
+	first := openSession(t, ts, "")
+	openSession(t, ts, "")
+	resp := streamableDo(t, ts, http.MethodPost, "", "", "application/json", initializeRequest)
+	if resp.status != http.StatusServiceUnavailable || resp.header.Get("Retry-After") == "" {
+		t.Fatalf("initialize above the limit = %d %q, want %d with Retry-After", resp.status, resp.body, http.StatusServiceUnavailable)
+	}
+
+	if resp := streamableDo(t, ts, http.MethodDelete, first, "", "", ""); resp.status != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d", resp.status)
+	}
+	openSession(t, ts, "")
+}
+
+func TestStreamableHTTPSessionToken(t *testing.T) {
+	ts, _ := newStreamableTestServer(t, Configuration{TokenPassthrough: true})
+	id := openSession(t, ts, "alice-token")
+
+	ping := `{"jsonrpc":"2.0","id":2,"method":"ping"}`
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "session token", token: "alice-token", wantStatus: http.StatusOK},
+		{name: "other token", token: "bob-token", wantStatus: http.StatusUnauthorized},
+		{name: "no token", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := streamableDo(t, ts, http.MethodPost, id, tt.token, "application/json", ping)
+			if resp.status != tt.wantStatus {
+				t.Errorf("status = %d %q, want %d", resp.status, resp.body, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/ext"
+)
+
+// Action is what happens to a tool call matched by a rule.
+type Action string
+
+const (
+	// ActionAllow lets the tool call proceed without the confirmation matching confirm
+	// rules would require. It doesn't override deny rules.
+	ActionAllow Action = "allow"
+	// ActionDeny rejects the tool call.
+	ActionDeny Action = "deny"
+	// ActionConfirm requires the tool call to be planned and confirmed before it's executed.
+	ActionConfirm Action = "confirm"
+)
+
+// costLimit bounds the work a single expression may perform.
+const costLimit = 1000000
+
+// Rule applies its action to every tool call for which expression evaluates to true.
+type Rule struct {
+	Name       string `json:"name" mapstructure:"name"`
+	Expression string `json:"expression" mapstructure:"expression"`
+	Action     Action `json:"action" mapstructure:"action"`
+	Message    string `json:"message,omitempty" mapstructure:"message"`
+}
+
+// Input holds everything known about a tool call when policies are evaluated.
+type Input struct {
+	// Tool is the name of the called tool.
+	Tool string
+	// Arguments are the arguments of the call.
+	Arguments map[string]any
+	// Group, Version and Kind identify the type of the target resource, if any.
+	Group   string
+	Version string
+	Kind    string
+	// Namespace is the namespace targeted by the call.
+	Namespace string
+	// NamespaceLabels are the labels of Namespace.
+	NamespaceLabels map[string]string
+	// SessionID, Username and Groups identify who is calling.
+	SessionID string
+	Username  string
+	Groups    []string
+	// PrincipalUsername and PrincipalGroups identify who authenticated to the transport, if anyone.
+	PrincipalUsername string
+	PrincipalGroups   []string
+}
+
+// Decision is the outcome of evaluating the policies for a tool call.
+type Decision struct {
+	Action Action
+	// Rule is the name of the rule that decided, empty when no rule matched.
+	Rule    string
+	Message string
+}
+
+type program struct {
+	rule Rule
+	prg  cel.Program
+}
+
+// Engine evaluates compiled rules against tool calls.
+type Engine struct {
+	programs []program
+	// needsNamespaceLabels and needsUser are set when a rule reads the labels of the
+	// namespace, or the Kubernetes username and groups of the caller, which take API
+	// requests to resolve.
+	needsNamespaceLabels bool
+	needsUser            bool
+}
+
+// New compiles rules into an Engine.
+func New(rules []Rule) (*Engine, error) {
+	env, err := cel.NewEnv(
+		ext.Strings(),
+		cel.OptionalTypes(),
+		cel.Variable("tool", cel.StringType),
+		cel.Variable("arguments", cel.MapType(cel.StringType, cel.DynType)),
+		// namespace is a reserved word of CEL, the targeted namespace is resource.namespace
+		cel.Variable("resource", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("namespaceLabels", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("identity", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	e := &Engine{}
+	for i, rule := range rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("policy-%d", i)
+		}
+		if rule.Action != ActionAllow && rule.Action != ActionDeny && rule.Action != ActionConfirm {
+			return nil, fmt.Errorf("policy %s: action must be %q, %q or %q, got %q", rule.Name, ActionAllow, ActionDeny, ActionConfirm, rule.Action)
+		}
+		ast, iss := env.Compile(rule.Expression)
+		if iss.Err() != nil {
+			return nil, fmt.Errorf("policy %s: %w", rule.Name, iss.Err())
+		}
+		if ast.OutputType() != cel.BoolType {
+			return nil, fmt.Errorf("policy %s: expression must evaluate to a bool, got %s", rule.Name, ast.OutputType())
+		}
+		prg, err := env.Program(ast, cel.CostLimit(costLimit), cel.InterruptCheckFrequency(100))
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %w", rule.Name, err)
+		}
+		e.programs = append(e.programs, program{rule: rule, prg: prg})
+		namespaceLabels, user := references(ast)
+		e.needsNamespaceLabels = e.needsNamespaceLabels || namespaceLabels
+		e.needsUser = e.needsUser || user
+	}
+	return e, nil
+}
+
+// references reports whether the checked expression reads namespaceLabels, and whether it
+// reads the identity of the caller beyond its session ID and principal.
+func references(ast *cel.Ast) (namespaceLabels, user bool) {
+	native := ast.NativeRep()
+	// The identity variables selecting fields known without asking the API server
+	resolved := map[int64]bool{}
+	celast.PreOrderVisit(native.Expr(), celast.NewExprVisitor(func(e celast.Expr) {
+		if e.Kind() != celast.SelectKind {
+			return
+		}
+		operand := e.AsSelect().Operand()
+		switch e.AsSelect().FieldName() {
+		case "sessionId", "principal":
+			if operand.Kind() == celast.IdentKind && operand.AsIdent() == "identity" {
+				resolved[operand.ID()] = true
+			}
+		}
+	}))
+	for id, reference := range native.ReferenceMap() {
+		switch reference.Name {
+		case "namespaceLabels":
+			namespaceLabels = true
+		case "identity":
+			user = user || !resolved[id]
+		}
+	}
+	return namespaceLabels, user
+}
+
+// NeedsNamespaceLabels reports whether a rule reads Input.NamespaceLabels.
+func (e *Engine) NeedsNamespaceLabels() bool {
+	return e != nil && e.needsNamespaceLabels
+}
+
+// NeedsUser reports whether a rule reads Input.Username or Input.Groups.
+func (e *Engine) NeedsUser() bool {
+	return e != nil && e.needsUser
+}
+
+// Empty reports whether the engine has no rules, in which case every call is allowed.
+func (e *Engine) Empty() bool {
+	return e == nil || len(e.programs) == 0
+}
+
+// Evaluate runs every rule against in. A matching deny rule wins over every other rule,
+// and a matching allow rule over the confirm rules, otherwise the first matching confirm
+// rule decides. Rules that fail to evaluate deny the call.
+func (e *Engine) Evaluate(ctx context.Context, in *Input) Decision {
+	if e.Empty() {
+		return Decision{Action: ActionAllow}
+	}
+
+	activation := map[string]any{
+		"tool":      in.Tool,
+		"arguments": emptyIfNil(in.Arguments),
+		"resource": map[string]string{
+			"group":     in.Group,
+			"version":   in.Version,
+			"kind":      in.Kind,
+			"namespace": in.Namespace,
+		},
+		"namespaceLabels": emptyIfNil(in.NamespaceLabels),
+		"identity": map[string]any{
+			"sessionId": in.SessionID,
+			"username":  in.Username,
+			"groups":    emptyIfNilSlice(in.Groups),
+			"principal": map[string]any{
+				"username": in.PrincipalUsername,
+				"groups":   emptyIfNilSlice(in.PrincipalGroups),
+			},
+		},
+	}
+
+	decision := Decision{Action: ActionAllow}
+	for _, p := range e.programs {
+		out, _, err := p.prg.ContextEval(ctx, activation)
+		if err != nil {
+			return Decision{
+				Action:  ActionDeny,
+				Rule:    p.rule.Name,
+				Message: fmt.Sprintf("policy %s failed to evaluate: %v", p.rule.Name, err),
+			}
+		}
+		if matched, _ := out.Value().(bool); !matched {
+			continue
+		}
+		switch {
+		case p.rule.Action == ActionDeny:
+			return decisionFor(p.rule)
+		case decision.Rule == "", p.rule.Action == ActionAllow && decision.Action == ActionConfirm:
+			decision = decisionFor(p.rule)
+		}
+	}
+	return decision
+}
+
+func decisionFor(rule Rule) Decision {
+	message := rule.Message
+	if message == "" {
+		message = fmt.Sprintf("matched policy %s", rule.Name)
+	}
+	return Decision{Action: rule.Action, Rule: rule.Name, Message: message}
+}
+
+func emptyIfNil[M ~map[string]V, V any](m M) M {
+	if m == nil {
+		return M{}
+	}
+	return m
+}
+
+func emptyIfNilSlice[S ~[]E, E any](s S) S {
+	if s == nil {
+		return S{}
+	}
+	return s
+}
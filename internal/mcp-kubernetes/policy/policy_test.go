@@ -0,0 +1,247 @@
+package policy
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name                     string
+		rules                    []Rule
+		wantErr                  bool
+		wantNeedsNamespaceLabels bool
+		wantNeedsUser            bool
+	}{
+		{
+			name: "no rules",
+		},
+		{
+			name:  "tool and arguments only",
+			rules: []Rule{{Expression: `tool == "resources_delete" && arguments.kind == "Namespace"`, Action: ActionDeny}},
+		},
+		{
+			name:                     "namespace labels",
+			rules:                    []Rule{{Expression: `namespaceLabels["env"] == "prod"`, Action: ActionConfirm}},
+			wantNeedsNamespaceLabels: true,
+		},
+		{
+			name:          "username",
+			rules:         []Rule{{Expression: `identity.username == "alice"`, Action: ActionDeny}},
+			wantNeedsUser: true,
+		},
+		{
+			name:          "groups by index",
+			rules:         []Rule{{Expression: `"admins" in identity["groups"]`, Action: ActionDeny}},
+			wantNeedsUser: true,
+		},
+		{
+			name: "session and principal",
+			rules: []Rule{{
+				Expression: `identity.sessionId == "stdio" || identity.principal.username == "alice"`,
+				Action:     ActionDeny,
+			}},
+		},
+		{
+			name: "any of the rules",
+			rules: []Rule{
+				{Expression: `namespaceLabels["env"] == "prod"`, Action: ActionConfirm},
+				{Expression: `identity.username == "alice"`, Action: ActionDeny},
+			},
+			wantNeedsNamespaceLabels: true,
+			wantNeedsUser:            true,
+		},
+		{
+			name:  "allow action",
+			rules: []Rule{{Expression: `true`, Action: ActionAllow}},
+		},
+		{
+			name:    "unknown action",
+			rules:   []Rule{{Expression: `true`, Action: "audit"}},
+			wantErr: true,
+		},
+		{
+			name:    "object is not a variable",
+			rules:   []Rule{{Expression: `has(object.spec)`, Action: ActionDeny}},
+			wantErr: true,
+		},
+		{
+			name:    "syntax error",
+			rules:   []Rule{{Expression: `tool ==`, Action: ActionDeny}},
+			wantErr: true,
+		},
+		{
+			name:    "undeclared variable",
+			rules:   []Rule{{Expression: `verb == "delete"`, Action: ActionDeny}},
+			wantErr: true,
+		},
+		{
+			name:    "not a bool",
+			rules:   []Rule{{Expression: `tool`, Action: ActionDeny}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := e.NeedsNamespaceLabels(); got != tt.wantNeedsNamespaceLabels {
+				t.Errorf("NeedsNamespaceLabels() = %v, want %v", got, tt.wantNeedsNamespaceLabels)
+			}
+			if got := e.NeedsUser(); got != tt.wantNeedsUser {
+				t.Errorf("NeedsUser() = %v, want %v", got, tt.wantNeedsUser)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	rules := []Rule{
+		{
+			Name:       "confirm-prod",
+			Expression: `namespaceLabels.?env.orValue("") == "prod"`,
+			Action:     ActionConfirm,
+			Message:    "production namespaces require a confirmation",
+		},
+		{
+			Name:       "deny-kube-system-deletes",
+			Expression: `tool == "resources_delete" && resource.namespace == "kube-system"`,
+			Action:     ActionDeny,
+		},
+		{
+			Name:       "allow-admins",
+			Expression: `"admins" in identity.groups`,
+			Action:     ActionAllow,
+		},
+		{
+			Name:       "confirm-interns",
+			Expression: `"interns" in identity.groups`,
+			Action:     ActionConfirm,
+		},
+		{
+			Name:       "deny-deletes-by-bob",
+			Expression: `tool.endsWith("_delete") && identity.principal.username == "bob"`,
+			Action:     ActionDeny,
+		},
+		{
+			Name:       "fails-without-replicas",
+			Expression: `tool == "resources_scale" && arguments.replicas > 10.0`,
+			Action:     ActionConfirm,
+		},
+	}
+	e, err := New(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		in   *Input
+		want Decision
+	}{
+		{
+			name: "no match",
+			in:   &Input{Tool: "pods_list", Namespace: "default"},
+			want: Decision{Action: ActionAllow},
+		},
+		{
+			name: "confirm",
+			in:   &Input{Tool: "pods_list", Namespace: "shop", NamespaceLabels: map[string]string{"env": "prod"}},
+			want: Decision{Action: ActionConfirm, Rule: "confirm-prod", Message: "production namespaces require a confirmation"},
+		},
+		{
+			name: "deny",
+			in:   &Input{Tool: "resources_delete", Namespace: "kube-system"},
+			want: Decision{Action: ActionDeny, Rule: "deny-kube-system-deletes", Message: "matched policy deny-kube-system-deletes"},
+		},
+		{
+			name: "deny beats an earlier confirm",
+			in: &Input{
+				Tool:            "resources_delete",
+				Namespace:       "kube-system",
+				NamespaceLabels: map[string]string{"env": "prod"},
+			},
+			want: Decision{Action: ActionDeny, Rule: "deny-kube-system-deletes", Message: "matched policy deny-kube-system-deletes"},
+		},
+		{
+			name: "allow beats an earlier confirm",
+			in: &Input{
+				Tool:            "pods_list",
+				NamespaceLabels: map[string]string{"env": "prod"},
+				Groups:          []string{"admins"},
+			},
+			want: Decision{Action: ActionAllow, Rule: "allow-admins", Message: "matched policy allow-admins"},
+		},
+		{
+			name: "allow beats a later confirm",
+			in: &Input{
+				Tool:   "pods_list",
+				Groups: []string{"admins", "interns"},
+			},
+			want: Decision{Action: ActionAllow, Rule: "allow-admins", Message: "matched policy allow-admins"},
+		},
+		{
+			name: "deny beats an earlier allow",
+			in: &Input{
+				Tool:              "pods_delete",
+				Groups:            []string{"admins"},
+				PrincipalUsername: "bob",
+			},
+			want: Decision{Action: ActionDeny, Rule: "deny-deletes-by-bob", Message: "matched policy deny-deletes-by-bob"},
+		},
+		{
+			name: "deny beats a later confirm",
+			in: &Input{
+				Tool:            "resources_delete",
+				Namespace:       "kube-system",
+				NamespaceLabels: map[string]string{"env": "prod"},
+				Groups:          []string{"interns"},
+			},
+			want: Decision{Action: ActionDeny, Rule: "deny-kube-system-deletes", Message: "matched policy deny-kube-system-deletes"},
+		},
+		{
+			name: "first confirm of several",
+			in: &Input{
+				Tool:            "pods_list",
+				NamespaceLabels: map[string]string{"env": "prod"},
+				Groups:          []string{"interns"},
+			},
+			want: Decision{Action: ActionConfirm, Rule: "confirm-prod", Message: "production namespaces require a confirmation"},
+		},
+		{
+			name: "principal",
+			in:   &Input{Tool: "pods_delete", PrincipalUsername: "bob"},
+			want: Decision{Action: ActionDeny, Rule: "deny-deletes-by-bob", Message: "matched policy deny-deletes-by-bob"},
+		},
+		{
+			name: "evaluation error denies",
+			in:   &Input{Tool: "resources_scale", Arguments: map[string]any{"name": "web"}},
+			want: Decision{
+				Action:  ActionDeny,
+				Rule:    "fails-without-replicas",
+				Message: "policy fails-without-replicas failed to evaluate: no such key: replicas",
+			},
+		},
+		{
+			name: "numeric argument",
+			in:   &Input{Tool: "resources_scale", Arguments: map[string]any{"replicas": 20.0}},
+			want: Decision{Action: ActionConfirm, Rule: "fails-without-replicas", Message: "matched policy fails-without-replicas"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.Evaluate(context.Background(), tt.in); got != tt.want {
+				t.Errorf("Evaluate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateEmpty(t *testing.T) {
+	for _, e := range []*Engine{nil, {}} {
+		if got := e.Evaluate(context.Background(), &Input{Tool: "resources_delete"}); got != (Decision{Action: ActionAllow}) {
+			t.Errorf("Evaluate() = %+v, want allow", got)
+		}
+	}
+}
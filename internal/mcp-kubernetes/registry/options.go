@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+type Options struct {
+	// Enabled registers the SSE and HTTP listeners of the server in Consul.
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// ServiceName is the name the listeners are registered under.
+	ServiceName string `json:"service-name" mapstructure:"service-name"`
+	// Address is advertised to the clients, the address the listeners are bound to if empty,
+	// or the address of the Consul agent node if they're bound to all interfaces.
+	Address string `json:"address,omitempty" mapstructure:"address"`
+	// Tags are added to the transport, cluster and context tags of the listeners.
+	Tags []string `json:"tags,omitempty" mapstructure:"tags"`
+	// CheckInterval is how often Consul checks the health of the listeners.
+	CheckInterval time.Duration `json:"check-interval" mapstructure:"check-interval"`
+	// DeregisterAfter is how long a listener may stay critical before Consul deregisters it.
+	DeregisterAfter time.Duration `json:"deregister-after" mapstructure:"deregister-after"`
+}
+
+func NewOptions() *Options {
+	return &Options{
+		ServiceName:     "mcp-kubernetes",
+		CheckInterval:   10 * time.Second,
+		DeregisterAfter: time.Minute,
+	}
+}
+
+func (o *Options) Validate() []error {
+	errs := []error{}
+	if !o.Enabled {
+		return errs
+	}
+	if o.ServiceName == "" {
+		errs = append(errs, fmt.Errorf("--registry.service-name cannot be empty"))
+	}
+	if o.CheckInterval <= 0 {
+		errs = append(errs, fmt.Errorf("--registry.check-interval must be greater than 0"))
+	}
+	if o.DeregisterAfter < 0 {
+		errs = append(errs, fmt.Errorf("--registry.deregister-after cannot be negative"))
+	}
+	return errs
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "registry.enabled", o.Enabled, "Register the SSE and HTTP listeners in the Consul agent of --consul.addr, "+
+		"with a health check, and deregister them on shutdown.")
+	fs.StringVar(&o.ServiceName, "registry.service-name", o.ServiceName, "Name of the Consul service the listeners are registered under.")
+	fs.StringVar(&o.Address, "registry.address", o.Address, "Address advertised in Consul. Defaults to the address the listeners "+
+		"are bound to, or to the address of the Consul agent node if they're bound to all interfaces.")
+	fs.StringSliceVar(&o.Tags, "registry.tags", o.Tags, "Tags added to the transport, cluster and context tags of the listeners.")
+	fs.DurationVar(&o.CheckInterval, "registry.check-interval", o.CheckInterval, "How often Consul checks the health of the listeners.")
+	fs.DurationVar(&o.DeregisterAfter, "registry.deregister-after", o.DeregisterAfter, "How long a listener may fail its health check "+
+		"before Consul deregisters it, never if 0.")
+}
@@ -0,0 +1,193 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	genericoptions "github.com/fleezesd/mcp-kubernetes/pkg/options"
+	"github.com/fleezesd/mcp-kubernetes/pkg/version"
+	"github.com/hashicorp/consul/api"
+)
+
+// Instance is a listener of the server, registered as an instance of the service.
+type Instance struct {
+	// Host and Port are the address the listener is bound to.
+	Host string
+	Port int
+	// Transports are the transports served by the listener, e.g. sse and http.
+	Transports []string
+	// HealthPath is the path of the health endpoint of the listener.
+	HealthPath string
+	// BasePath is the prefix of the paths of the MCP endpoints of the listener, if any.
+	BasePath string
+	// TLS is set when the listener serves TLS.
+	TLS bool
+	// ClientCerts is set when the listener requires client certificates, which
+	// Consul doesn't present. Such listeners are only checked to accept connections.
+	ClientCerts bool
+}
+
+// Registry registers the listeners of the server in a Consul agent.
+type Registry struct {
+	options   *Options
+	agent     *api.Agent
+	hostname  string
+	mu        sync.Mutex
+	instances []Instance
+	tags      []string
+	// registered are the service IDs currently registered.
+	registered []string
+}
+
+// New returns a Registry for the Consul agent of consul, or nil if registration is disabled.
+func New(opts *Options, consul *genericoptions.ConsulOptions) (*Registry, error) {
+	if opts == nil || !opts.Enabled {
+		return nil, nil
+	}
+	config := api.DefaultConfig()
+	if consul != nil {
+		config.Address = consul.Addr
+		config.Scheme = consul.Scheme
+	}
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consul client: %w", err)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hostname: %w", err)
+	}
+	return &Registry{options: opts, agent: client.Agent(), hostname: hostname}, nil
+}
+
+// Register registers instances with tags in addition to the configured ones,
+// replacing the instances registered before.
+func (r *Registry) Register(ctx context.Context, instances []Instance, tags []string) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.instances = instances
+	r.tags = tags
+	return r.register(ctx)
+}
+
+// SetTags registers the instances again with tags in addition to the configured ones.
+func (r *Registry) SetTags(ctx context.Context, tags []string) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.Equal(r.tags, tags) {
+		return nil
+	}
+	r.tags = tags
+	return r.register(ctx)
+}
+
+// Deregister removes the registered instances from Consul.
+func (r *Registry) Deregister(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for _, id := range r.registered {
+		if err := r.agent.ServiceDeregisterOpts(id, (&api.QueryOptions{}).WithContext(ctx)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to deregister %s from consul: %w", id, err))
+		}
+	}
+	r.registered = nil
+	return errors.Join(errs...)
+}
+
+func (r *Registry) register(ctx context.Context) error {
+	var registered []string
+	for _, instance := range r.instances {
+		registration := r.registration(instance)
+		if err := r.agent.ServiceRegisterOpts(registration, api.ServiceRegisterOpts{}.WithContext(ctx)); err != nil {
+			return fmt.Errorf("failed to register %s in consul: %w", registration.ID, err)
+		}
+		registered = append(registered, registration.ID)
+	}
+	r.registered = registered
+	return nil
+}
+
+func (r *Registry) registration(instance Instance) *api.AgentServiceRegistration {
+	address := r.options.Address
+	if address == "" && !isUnspecified(instance.Host) {
+		address = instance.Host
+	}
+	// The agent checks the listener from its own node when the node address is advertised
+	checkHost := address
+	if checkHost == "" {
+		checkHost = "127.0.0.1"
+	}
+	checkAddr := net.JoinHostPort(checkHost, strconv.Itoa(instance.Port))
+
+	check := &api.AgentServiceCheck{
+		Interval: r.options.CheckInterval.String(),
+		Timeout:  r.options.CheckInterval.String(),
+	}
+	if r.options.DeregisterAfter > 0 {
+		check.DeregisterCriticalServiceAfter = r.options.DeregisterAfter.String()
+	}
+	switch {
+	case instance.ClientCerts:
+		check.TCP = checkAddr
+	case instance.TLS:
+		check.HTTP = "https://" + checkAddr + instance.HealthPath
+		check.Method = http.MethodGet
+		// The certificate is usually issued for the advertised name, not for the checked address
+		check.TLSSkipVerify = true
+	default:
+		check.HTTP = "http://" + checkAddr + instance.HealthPath
+		check.Method = http.MethodGet
+	}
+
+	tags := make([]string, 0, len(instance.Transports)+len(r.tags)+len(r.options.Tags))
+	for _, transport := range instance.Transports {
+		tags = append(tags, "transport="+transport)
+	}
+	tags = append(tags, r.tags...)
+	tags = append(tags, r.options.Tags...)
+
+	meta := map[string]string{
+		"version":    version.Get().GitVersion,
+		"transports": strings.Join(instance.Transports, ","),
+	}
+	if instance.BasePath != "" {
+		meta["base-path"] = instance.BasePath
+	}
+
+	return &api.AgentServiceRegistration{
+		ID:      fmt.Sprintf("%s-%s-%d", r.options.ServiceName, r.hostname, instance.Port),
+		Name:    r.options.ServiceName,
+		Address: address,
+		Port:    instance.Port,
+		Tags:    tags,
+		Meta:    meta,
+		Check:   check,
+	}
+}
+
+// isUnspecified reports whether host is empty or binds all interfaces.
+func isUnspecified(host string) bool {
+	if host == "" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
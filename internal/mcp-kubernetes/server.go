@@ -1,13 +1,57 @@
 package mcpkubernetes
 
 import (
+	"crypto/tls"
+	"time"
+
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/audit"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/authn"
 	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/mcp"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/policy"
+	"github.com/fleezesd/mcp-kubernetes/internal/mcp-kubernetes/registry"
+	genericoptions "github.com/fleezesd/mcp-kubernetes/pkg/options"
 )
 
 type Config struct {
-	SSEBaseURL string
-	SSEPort    int
-	KubeConfig string
+	Transports         []string
+	SSEBaseURL         string
+	SSEPort            int
+	HTTPPort           int
+	KubeConfig         string
+	TokenPassthrough   bool
+	RevealCredentials  bool
+	ReadOnly           bool
+	EnabledTools       []string
+	DisabledTools      []string
+	AllowedNamespaces  []string
+	DeniedNamespaces   []string
+	ConfirmDestructive bool
+	ConfirmationTTL    time.Duration
+	Policies           []policy.Rule
+	Audit              *audit.Options
+	SessionRate        float64
+	SessionBurst       int
+	ToolRate           float64
+	ToolBurst          int
+	MaxConcurrentCalls int
+	KubeAPIQPS         float32
+	KubeAPIBurst       int
+	ToolTimeout        time.Duration
+	ToolTimeouts       map[string]time.Duration
+	MaxToolTimeout     time.Duration
+	TLS                *genericoptions.TLSOptions
+	Authn              *authn.Options
+	HTTP               *genericoptions.HTTPOptions
+	GRPC               *genericoptions.GRPCOptions
+	AllowedOrigins     []string
+	AllowedHosts       []string
+	CORSMaxAge         time.Duration
+	HTTPBasePath       string
+	MaxSessions        int
+	SessionIdleTimeout time.Duration
+	SessionAdmins      []string
+	Registry           *registry.Options
+	Consul             *genericoptions.ConsulOptions
 }
 
 type CompletedConfig struct {
@@ -19,5 +63,48 @@ func (c *Config) Complete() CompletedConfig {
 }
 
 func (c *Config) New() (*mcp.Server, error) {
-	return mcp.NewServer(c.KubeConfig)
+	var tlsConfig *tls.Config
+	if c.TLS != nil {
+		var err error
+		if tlsConfig, err = c.TLS.ServerTLSConfig(); err != nil {
+			return nil, err
+		}
+	}
+	return mcp.NewServer(mcp.Configuration{
+		KubeConfig:         c.KubeConfig,
+		TokenPassthrough:   c.TokenPassthrough,
+		RevealCredentials:  c.RevealCredentials,
+		ReadOnly:           c.ReadOnly,
+		EnabledTools:       c.EnabledTools,
+		DisabledTools:      c.DisabledTools,
+		AllowedNamespaces:  c.AllowedNamespaces,
+		DeniedNamespaces:   c.DeniedNamespaces,
+		ConfirmDestructive: c.ConfirmDestructive,
+		ConfirmationTTL:    c.ConfirmationTTL,
+		Policies:           c.Policies,
+		Audit:              c.Audit,
+		SessionRate:        c.SessionRate,
+		SessionBurst:       c.SessionBurst,
+		ToolRate:           c.ToolRate,
+		ToolBurst:          c.ToolBurst,
+		MaxConcurrentCalls: c.MaxConcurrentCalls,
+		KubeAPIQPS:         c.KubeAPIQPS,
+		KubeAPIBurst:       c.KubeAPIBurst,
+		ToolTimeout:        c.ToolTimeout,
+		ToolTimeouts:       c.ToolTimeouts,
+		MaxToolTimeout:     c.MaxToolTimeout,
+		TLSConfig:          tlsConfig,
+		Authn:              c.Authn,
+		HTTP:               c.HTTP,
+		GRPC:               c.GRPC,
+		AllowedOrigins:     c.AllowedOrigins,
+		AllowedHosts:       c.AllowedHosts,
+		CORSMaxAge:         c.CORSMaxAge,
+		HTTPBasePath:       c.HTTPBasePath,
+		MaxSessions:        c.MaxSessions,
+		SessionIdleTimeout: c.SessionIdleTimeout,
+		SessionAdmins:      c.SessionAdmins,
+		Registry:           c.Registry,
+		Consul:             c.Consul,
+	})
 }
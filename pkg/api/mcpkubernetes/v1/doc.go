@@ -0,0 +1,4 @@
+// Package v1 contains the gRPC API of the mcp-kubernetes server.
+package v1
+
+//go:generate protoc -I .. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative mcpkubernetes/v1/tool.proto
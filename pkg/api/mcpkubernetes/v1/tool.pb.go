@@ -0,0 +1,740 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: mcpkubernetes/v1/tool.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListToolsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToolsRequest) Reset() {
+	*x = ListToolsRequest{}
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToolsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToolsRequest) ProtoMessage() {}
+
+func (x *ListToolsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToolsRequest.ProtoReflect.Descriptor instead.
+func (*ListToolsRequest) Descriptor() ([]byte, []int) {
+	return file_mcpkubernetes_v1_tool_proto_rawDescGZIP(), []int{0}
+}
+
+type ListToolsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tools         []*Tool                `protobuf:"bytes,1,rep,name=tools,proto3" json:"tools,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToolsResponse) Reset() {
+	*x = ListToolsResponse{}
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToolsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToolsResponse) ProtoMessage() {}
+
+func (x *ListToolsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToolsResponse.ProtoReflect.Descriptor instead.
+func (*ListToolsResponse) Descriptor() ([]byte, []int) {
+	return file_mcpkubernetes_v1_tool_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListToolsResponse) GetTools() []*Tool {
+	if x != nil {
+		return x.Tools
+	}
+	return nil
+}
+
+type GetToolRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetToolRequest) Reset() {
+	*x = GetToolRequest{}
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetToolRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetToolRequest) ProtoMessage() {}
+
+func (x *GetToolRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetToolRequest.ProtoReflect.Descriptor instead.
+func (*GetToolRequest) Descriptor() ([]byte, []int) {
+	return file_mcpkubernetes_v1_tool_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetToolRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type Tool struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// input_schema is the JSON schema of the tool arguments.
+	InputSchema   *structpb.Struct `protobuf:"bytes,3,opt,name=input_schema,json=inputSchema,proto3" json:"input_schema,omitempty"`
+	Annotations   *ToolAnnotations `protobuf:"bytes,4,opt,name=annotations,proto3" json:"annotations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tool) Reset() {
+	*x = Tool{}
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tool) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tool) ProtoMessage() {}
+
+func (x *Tool) ProtoReflect() protoreflect.Message {
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tool.ProtoReflect.Descriptor instead.
+func (*Tool) Descriptor() ([]byte, []int) {
+	return file_mcpkubernetes_v1_tool_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Tool) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tool) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Tool) GetInputSchema() *structpb.Struct {
+	if x != nil {
+		return x.InputSchema
+	}
+	return nil
+}
+
+func (x *Tool) GetAnnotations() *ToolAnnotations {
+	if x != nil {
+		return x.Annotations
+	}
+	return nil
+}
+
+// ToolAnnotations are hints about the behavior of a tool.
+type ToolAnnotations struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	ReadOnly      bool                   `protobuf:"varint,2,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	Destructive   bool                   `protobuf:"varint,3,opt,name=destructive,proto3" json:"destructive,omitempty"`
+	Idempotent    bool                   `protobuf:"varint,4,opt,name=idempotent,proto3" json:"idempotent,omitempty"`
+	OpenWorld     bool                   `protobuf:"varint,5,opt,name=open_world,json=openWorld,proto3" json:"open_world,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolAnnotations) Reset() {
+	*x = ToolAnnotations{}
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolAnnotations) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolAnnotations) ProtoMessage() {}
+
+func (x *ToolAnnotations) ProtoReflect() protoreflect.Message {
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolAnnotations.ProtoReflect.Descriptor instead.
+func (*ToolAnnotations) Descriptor() ([]byte, []int) {
+	return file_mcpkubernetes_v1_tool_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ToolAnnotations) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *ToolAnnotations) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *ToolAnnotations) GetDestructive() bool {
+	if x != nil {
+		return x.Destructive
+	}
+	return false
+}
+
+func (x *ToolAnnotations) GetIdempotent() bool {
+	if x != nil {
+		return x.Idempotent
+	}
+	return false
+}
+
+func (x *ToolAnnotations) GetOpenWorld() bool {
+	if x != nil {
+		return x.OpenWorld
+	}
+	return false
+}
+
+type CallToolRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Arguments     *structpb.Struct       `protobuf:"bytes,2,opt,name=arguments,proto3" json:"arguments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CallToolRequest) Reset() {
+	*x = CallToolRequest{}
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CallToolRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallToolRequest) ProtoMessage() {}
+
+func (x *CallToolRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallToolRequest.ProtoReflect.Descriptor instead.
+func (*CallToolRequest) Descriptor() ([]byte, []int) {
+	return file_mcpkubernetes_v1_tool_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CallToolRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CallToolRequest) GetArguments() *structpb.Struct {
+	if x != nil {
+		return x.Arguments
+	}
+	return nil
+}
+
+type CallToolResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Content []*Content             `protobuf:"bytes,1,rep,name=content,proto3" json:"content,omitempty"`
+	// is_error is set when the tool call failed, content then describes the error.
+	IsError       bool `protobuf:"varint,2,opt,name=is_error,json=isError,proto3" json:"is_error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CallToolResponse) Reset() {
+	*x = CallToolResponse{}
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CallToolResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallToolResponse) ProtoMessage() {}
+
+func (x *CallToolResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallToolResponse.ProtoReflect.Descriptor instead.
+func (*CallToolResponse) Descriptor() ([]byte, []int) {
+	return file_mcpkubernetes_v1_tool_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CallToolResponse) GetContent() []*Content {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *CallToolResponse) GetIsError() bool {
+	if x != nil {
+		return x.IsError
+	}
+	return false
+}
+
+// Content is an item of a tool result: text, base64 encoded image data or an
+// embedded resource depending on type.
+type Content struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Data          string                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	MimeType      string                 `protobuf:"bytes,4,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Resource      *structpb.Struct       `protobuf:"bytes,5,opt,name=resource,proto3" json:"resource,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Content) Reset() {
+	*x = Content{}
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Content) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Content) ProtoMessage() {}
+
+func (x *Content) ProtoReflect() protoreflect.Message {
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Content.ProtoReflect.Descriptor instead.
+func (*Content) Descriptor() ([]byte, []int) {
+	return file_mcpkubernetes_v1_tool_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Content) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Content) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Content) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *Content) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *Content) GetResource() *structpb.Struct {
+	if x != nil {
+		return x.Resource
+	}
+	return nil
+}
+
+// Notification is an MCP notification sent by a tool while it runs.
+type Notification struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Method        string                 `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	Params        *structpb.Struct       `protobuf:"bytes,2,opt,name=params,proto3" json:"params,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Notification) Reset() {
+	*x = Notification{}
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Notification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Notification) ProtoMessage() {}
+
+func (x *Notification) ProtoReflect() protoreflect.Message {
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Notification.ProtoReflect.Descriptor instead.
+func (*Notification) Descriptor() ([]byte, []int) {
+	return file_mcpkubernetes_v1_tool_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Notification) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *Notification) GetParams() *structpb.Struct {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+type StreamToolResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*StreamToolResponse_Notification
+	//	*StreamToolResponse_Result
+	Event         isStreamToolResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamToolResponse) Reset() {
+	*x = StreamToolResponse{}
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamToolResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamToolResponse) ProtoMessage() {}
+
+func (x *StreamToolResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcpkubernetes_v1_tool_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamToolResponse.ProtoReflect.Descriptor instead.
+func (*StreamToolResponse) Descriptor() ([]byte, []int) {
+	return file_mcpkubernetes_v1_tool_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StreamToolResponse) GetEvent() isStreamToolResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *StreamToolResponse) GetNotification() *Notification {
+	if x != nil {
+		if x, ok := x.Event.(*StreamToolResponse_Notification); ok {
+			return x.Notification
+		}
+	}
+	return nil
+}
+
+func (x *StreamToolResponse) GetResult() *CallToolResponse {
+	if x != nil {
+		if x, ok := x.Event.(*StreamToolResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isStreamToolResponse_Event interface {
+	isStreamToolResponse_Event()
+}
+
+type StreamToolResponse_Notification struct {
+	Notification *Notification `protobuf:"bytes,1,opt,name=notification,proto3,oneof"`
+}
+
+type StreamToolResponse_Result struct {
+	Result *CallToolResponse `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*StreamToolResponse_Notification) isStreamToolResponse_Event() {}
+
+func (*StreamToolResponse_Result) isStreamToolResponse_Event() {}
+
+var File_mcpkubernetes_v1_tool_proto protoreflect.FileDescriptor
+
+const file_mcpkubernetes_v1_tool_proto_rawDesc = "" +
+	"\n" +
+	"\x1bmcpkubernetes/v1/tool.proto\x12\x10mcpkubernetes.v1\x1a\x1cgoogle/protobuf/struct.proto\"\x12\n" +
+	"\x10ListToolsRequest\"A\n" +
+	"\x11ListToolsResponse\x12,\n" +
+	"\x05tools\x18\x01 \x03(\v2\x16.mcpkubernetes.v1.ToolR\x05tools\"$\n" +
+	"\x0eGetToolRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\xbd\x01\n" +
+	"\x04Tool\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12:\n" +
+	"\finput_schema\x18\x03 \x01(\v2\x17.google.protobuf.StructR\vinputSchema\x12C\n" +
+	"\vannotations\x18\x04 \x01(\v2!.mcpkubernetes.v1.ToolAnnotationsR\vannotations\"\xa5\x01\n" +
+	"\x0fToolAnnotations\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x1b\n" +
+	"\tread_only\x18\x02 \x01(\bR\breadOnly\x12 \n" +
+	"\vdestructive\x18\x03 \x01(\bR\vdestructive\x12\x1e\n" +
+	"\n" +
+	"idempotent\x18\x04 \x01(\bR\n" +
+	"idempotent\x12\x1d\n" +
+	"\n" +
+	"open_world\x18\x05 \x01(\bR\topenWorld\"\\\n" +
+	"\x0fCallToolRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x125\n" +
+	"\targuments\x18\x02 \x01(\v2\x17.google.protobuf.StructR\targuments\"b\n" +
+	"\x10CallToolResponse\x123\n" +
+	"\acontent\x18\x01 \x03(\v2\x19.mcpkubernetes.v1.ContentR\acontent\x12\x19\n" +
+	"\bis_error\x18\x02 \x01(\bR\aisError\"\x97\x01\n" +
+	"\aContent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\tR\x04data\x12\x1b\n" +
+	"\tmime_type\x18\x04 \x01(\tR\bmimeType\x123\n" +
+	"\bresource\x18\x05 \x01(\v2\x17.google.protobuf.StructR\bresource\"W\n" +
+	"\fNotification\x12\x16\n" +
+	"\x06method\x18\x01 \x01(\tR\x06method\x12/\n" +
+	"\x06params\x18\x02 \x01(\v2\x17.google.protobuf.StructR\x06params\"\xa1\x01\n" +
+	"\x12StreamToolResponse\x12D\n" +
+	"\fnotification\x18\x01 \x01(\v2\x1e.mcpkubernetes.v1.NotificationH\x00R\fnotification\x12<\n" +
+	"\x06result\x18\x02 \x01(\v2\".mcpkubernetes.v1.CallToolResponseH\x00R\x06resultB\a\n" +
+	"\x05event2\xd4\x02\n" +
+	"\vToolService\x12T\n" +
+	"\tListTools\x12\".mcpkubernetes.v1.ListToolsRequest\x1a#.mcpkubernetes.v1.ListToolsResponse\x12C\n" +
+	"\aGetTool\x12 .mcpkubernetes.v1.GetToolRequest\x1a\x16.mcpkubernetes.v1.Tool\x12Q\n" +
+	"\bCallTool\x12!.mcpkubernetes.v1.CallToolRequest\x1a\".mcpkubernetes.v1.CallToolResponse\x12W\n" +
+	"\n" +
+	"StreamTool\x12!.mcpkubernetes.v1.CallToolRequest\x1a$.mcpkubernetes.v1.StreamToolResponse0\x01B@Z>github.com/fleezesd/mcp-kubernetes/pkg/api/mcpkubernetes/v1;v1b\x06proto3"
+
+var (
+	file_mcpkubernetes_v1_tool_proto_rawDescOnce sync.Once
+	file_mcpkubernetes_v1_tool_proto_rawDescData []byte
+)
+
+func file_mcpkubernetes_v1_tool_proto_rawDescGZIP() []byte {
+	file_mcpkubernetes_v1_tool_proto_rawDescOnce.Do(func() {
+		file_mcpkubernetes_v1_tool_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_mcpkubernetes_v1_tool_proto_rawDesc), len(file_mcpkubernetes_v1_tool_proto_rawDesc)))
+	})
+	return file_mcpkubernetes_v1_tool_proto_rawDescData
+}
+
+var file_mcpkubernetes_v1_tool_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_mcpkubernetes_v1_tool_proto_goTypes = []any{
+	(*ListToolsRequest)(nil),   // 0: mcpkubernetes.v1.ListToolsRequest
+	(*ListToolsResponse)(nil),  // 1: mcpkubernetes.v1.ListToolsResponse
+	(*GetToolRequest)(nil),     // 2: mcpkubernetes.v1.GetToolRequest
+	(*Tool)(nil),               // 3: mcpkubernetes.v1.Tool
+	(*ToolAnnotations)(nil),    // 4: mcpkubernetes.v1.ToolAnnotations
+	(*CallToolRequest)(nil),    // 5: mcpkubernetes.v1.CallToolRequest
+	(*CallToolResponse)(nil),   // 6: mcpkubernetes.v1.CallToolResponse
+	(*Content)(nil),            // 7: mcpkubernetes.v1.Content
+	(*Notification)(nil),       // 8: mcpkubernetes.v1.Notification
+	(*StreamToolResponse)(nil), // 9: mcpkubernetes.v1.StreamToolResponse
+	(*structpb.Struct)(nil),    // 10: google.protobuf.Struct
+}
+var file_mcpkubernetes_v1_tool_proto_depIdxs = []int32{
+	3,  // 0: mcpkubernetes.v1.ListToolsResponse.tools:type_name -> mcpkubernetes.v1.Tool
+	10, // 1: mcpkubernetes.v1.Tool.input_schema:type_name -> google.protobuf.Struct
+	4,  // 2: mcpkubernetes.v1.Tool.annotations:type_name -> mcpkubernetes.v1.ToolAnnotations
+	10, // 3: mcpkubernetes.v1.CallToolRequest.arguments:type_name -> google.protobuf.Struct
+	7,  // 4: mcpkubernetes.v1.CallToolResponse.content:type_name -> mcpkubernetes.v1.Content
+	10, // 5: mcpkubernetes.v1.Content.resource:type_name -> google.protobuf.Struct
+	10, // 6: mcpkubernetes.v1.Notification.params:type_name -> google.protobuf.Struct
+	8,  // 7: mcpkubernetes.v1.StreamToolResponse.notification:type_name -> mcpkubernetes.v1.Notification
+	6,  // 8: mcpkubernetes.v1.StreamToolResponse.result:type_name -> mcpkubernetes.v1.CallToolResponse
+	0,  // 9: mcpkubernetes.v1.ToolService.ListTools:input_type -> mcpkubernetes.v1.ListToolsRequest
+	2,  // 10: mcpkubernetes.v1.ToolService.GetTool:input_type -> mcpkubernetes.v1.GetToolRequest
+	5,  // 11: mcpkubernetes.v1.ToolService.CallTool:input_type -> mcpkubernetes.v1.CallToolRequest
+	5,  // 12: mcpkubernetes.v1.ToolService.StreamTool:input_type -> mcpkubernetes.v1.CallToolRequest
+	1,  // 13: mcpkubernetes.v1.ToolService.ListTools:output_type -> mcpkubernetes.v1.ListToolsResponse
+	3,  // 14: mcpkubernetes.v1.ToolService.GetTool:output_type -> mcpkubernetes.v1.Tool
+	6,  // 15: mcpkubernetes.v1.ToolService.CallTool:output_type -> mcpkubernetes.v1.CallToolResponse
+	9,  // 16: mcpkubernetes.v1.ToolService.StreamTool:output_type -> mcpkubernetes.v1.StreamToolResponse
+	13, // [13:17] is the sub-list for method output_type
+	9,  // [9:13] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_mcpkubernetes_v1_tool_proto_init() }
+func file_mcpkubernetes_v1_tool_proto_init() {
+	if File_mcpkubernetes_v1_tool_proto != nil {
+		return
+	}
+	file_mcpkubernetes_v1_tool_proto_msgTypes[9].OneofWrappers = []any{
+		(*StreamToolResponse_Notification)(nil),
+		(*StreamToolResponse_Result)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_mcpkubernetes_v1_tool_proto_rawDesc), len(file_mcpkubernetes_v1_tool_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_mcpkubernetes_v1_tool_proto_goTypes,
+		DependencyIndexes: file_mcpkubernetes_v1_tool_proto_depIdxs,
+		MessageInfos:      file_mcpkubernetes_v1_tool_proto_msgTypes,
+	}.Build()
+	File_mcpkubernetes_v1_tool_proto = out.File
+	file_mcpkubernetes_v1_tool_proto_goTypes = nil
+	file_mcpkubernetes_v1_tool_proto_depIdxs = nil
+}
@@ -24,6 +24,7 @@ type App struct {
 	run         RunFunc
 	cmd         *cobra.Command
 	args        cobra.PositionalArgs
+	commands    []*cobra.Command
 
 	healthCheckFunc HealthCheckFunc
 
@@ -122,6 +123,13 @@ func WithDefaultValidArgs() Option {
 	}
 }
 
+// WithCommands adds subcommands to the application.
+func WithCommands(cmds ...*cobra.Command) Option {
+	return func(app *App) {
+		app.commands = append(app.commands, cmds...)
+	}
+}
+
 // WithWatchConfig watching and re-reading config files.
 func WithWatchConfig() Option {
 	return func(app *App) {
@@ -173,6 +181,7 @@ func (app *App) buildCommand() {
 	cmd.SetOut(os.Stdout)
 	cmd.SetErr(os.Stderr)
 	cmd.Flags().SortFlags = true
+	cmd.AddCommand(app.commands...)
 
 	var fss cliflag.NamedFlagSets
 	if app.options != nil {
@@ -3,7 +3,9 @@ package app
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/fleezesd/mcp-kubernetes/pkg/log"
 	"github.com/fsnotify/fsnotify"
@@ -17,6 +19,19 @@ const configFlagName = "config"
 
 var cfgFile string
 
+var (
+	configChangeMu       sync.Mutex
+	configChangeHandlers []func()
+)
+
+// OnConfigChange registers fn to be called after the configuration file is re-read.
+// It only takes effect for applications created with WithWatchConfig.
+func OnConfigChange(fn func()) {
+	configChangeMu.Lock()
+	defer configChangeMu.Unlock()
+	configChangeHandlers = append(configChangeHandlers, fn)
+}
+
 // AddConfigFlag adds flags for a specific server to the specified FlagSet object.
 // It also sets a passed functions to read values from configuration file into viper
 // when each cobra command's Execute method is called.
@@ -56,6 +71,13 @@ func AddConfigFlag(fs *pflag.FlagSet, name string, watch bool) {
 			viper.WatchConfig()
 			viper.OnConfigChange(func(e fsnotify.Event) {
 				log.Debugw("Config file changed", "name", e.Name)
+
+				configChangeMu.Lock()
+				handlers := slices.Clone(configChangeHandlers)
+				configChangeMu.Unlock()
+				for _, handler := range handlers {
+					handler()
+				}
 			})
 		}
 	})
@@ -0,0 +1,206 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// identity describes who the API server considers the client to be and
+// which client settings led to it.
+type identity struct {
+	Username    string              `json:"username"`
+	UID         string              `json:"uid,omitempty"`
+	Groups      []string            `json:"groups,omitempty"`
+	Extra       map[string][]string `json:"extra,omitempty"`
+	Context     string              `json:"context,omitempty"`
+	Cluster     string              `json:"cluster,omitempty"`
+	Server      string              `json:"server,omitempty"`
+	InCluster   bool                `json:"inCluster,omitempty"`
+	Impersonate *impersonation      `json:"impersonate,omitempty"`
+}
+
+type impersonation struct {
+	Username string              `json:"username,omitempty"`
+	UID      string              `json:"uid,omitempty"`
+	Groups   []string            `json:"groups,omitempty"`
+	Extra    map[string][]string `json:"extra,omitempty"`
+}
+
+// WhoAmI issues a SelfSubjectReview and returns the user attributes the API server
+// sees for the current credentials, together with the context, cluster and
+// impersonation settings in effect, as a marshaled string
+func (k *Kubernetes) WhoAmI(ctx context.Context) (string, error) {
+	userInfo, err := k.UserInfo(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	id := identity{
+		Username:  userInfo.Username,
+		UID:       userInfo.UID,
+		Groups:    userInfo.Groups,
+		Server:    k.cfg.Host,
+		InCluster: k.IsInCluster(),
+	}
+	if len(userInfo.Extra) > 0 {
+		id.Extra = make(map[string][]string, len(userInfo.Extra))
+		for key, value := range userInfo.Extra {
+			id.Extra[key] = value
+		}
+	}
+
+	if !id.InCluster {
+		id.Context, id.Cluster = k.CurrentContext()
+	}
+
+	if impersonate := k.cfg.Impersonate; impersonate.UserName != "" || impersonate.UID != "" || len(impersonate.Groups) > 0 {
+		id.Impersonate = &impersonation{
+			Username: impersonate.UserName,
+			UID:      impersonate.UID,
+			Groups:   impersonate.Groups,
+			Extra:    impersonate.Extra,
+		}
+	}
+
+	return marshal(id)
+}
+
+// UserInfo issues a SelfSubjectReview and returns the user attributes the API server
+// sees for the current credentials.
+func (k *Kubernetes) UserInfo(ctx context.Context) (*authenticationv1.UserInfo, error) {
+	review, err := k.clientSet.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create self subject review: %w", err)
+	}
+	return &review.Status.UserInfo, nil
+}
+
+// accessResult is the outcome of an access review together with the attributes it was issued for.
+type accessResult struct {
+	Allowed         bool   `json:"allowed"`
+	Denied          bool   `json:"denied,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+	EvaluationError string `json:"evaluationError,omitempty"`
+	Verb            string `json:"verb"`
+	Group           string `json:"group,omitempty"`
+	Resource        string `json:"resource"`
+	Subresource     string `json:"subresource,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+}
+
+// CanI issues a SelfSubjectAccessReview to verify if the current user may perform verb on
+// resource (e.g. pods, deployments.apps) and returns the outcome as a marshaled string.
+// When namespace is empty, namespaced resources are checked in the default namespace.
+func (k *Kubernetes) CanI(ctx context.Context, verb, resource, subresource, name, namespace string) (string, error) {
+	type resolved struct {
+		gvr        schema.GroupVersionResource
+		namespaced bool
+	}
+	r, err := discover(ctx, func() (resolved, error) {
+		gvr, namespaced := k.resolveResource(resource)
+		return resolved{gvr: gvr, namespaced: namespaced}, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	gvr, namespaced := r.gvr, r.namespaced
+	if namespaced && namespace == "" {
+		namespace = k.DefaultNamespace(ctx)
+	}
+	if namespace != "" {
+		if err := k.CheckNamespace(namespace); err != nil {
+			return "", err
+		}
+	}
+
+	attributes := &authv1.ResourceAttributes{
+		Namespace:   namespace,
+		Verb:        verb,
+		Group:       gvr.Group,
+		Version:     gvr.Version,
+		Resource:    gvr.Resource,
+		Subresource: subresource,
+		Name:        name,
+	}
+	status, err := k.accessReview(ctx, attributes)
+	if err != nil {
+		return "", err
+	}
+
+	return marshal(accessResult{
+		Allowed:         status.Allowed,
+		Denied:          status.Denied,
+		Reason:          status.Reason,
+		EvaluationError: status.EvaluationError,
+		Verb:            attributes.Verb,
+		Group:           attributes.Group,
+		Resource:        attributes.Resource,
+		Subresource:     attributes.Subresource,
+		Name:            attributes.Name,
+		Namespace:       attributes.Namespace,
+	})
+}
+
+// Rules issues a SelfSubjectRulesReview for the given namespace, or the default namespace
+// if empty, and returns the resource and non-resource rules as a marshaled string
+func (k *Kubernetes) Rules(ctx context.Context, namespace string) (string, error) {
+	if namespace == "" {
+		namespace = k.DefaultNamespace(ctx)
+	}
+	if err := k.CheckNamespace(namespace); err != nil {
+		return "", err
+	}
+
+	review := &authv1.SelfSubjectRulesReview{
+		Spec: authv1.SelfSubjectRulesReviewSpec{
+			Namespace: namespace,
+		},
+	}
+	response, err := k.clientSet.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create self subject rules review: %w", err)
+	}
+	return marshal(response.Status)
+}
+
+func (k *Kubernetes) accessReview(ctx context.Context, attributes *authv1.ResourceAttributes) (*authv1.SubjectAccessReviewStatus, error) {
+	review := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: attributes,
+		},
+	}
+	response, err := k.clientSet.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create self subject access review: %w", err)
+	}
+	return &response.Status, nil
+}
+
+// resolveResource maps a resource such as pods, pod or deployments.apps to its
+// GroupVersionResource using discovery, and reports whether it is namespaced.
+// Resources unknown to the API server, including wildcards, are returned as given.
+func (k *Kubernetes) resolveResource(resource string) (schema.GroupVersionResource, bool) {
+	gr := schema.ParseGroupResource(resource)
+	gvr := schema.GroupVersionResource{Group: gr.Group, Resource: gr.Resource}
+
+	resolved, err := k.deferredDiscoveryRESTMapper.ResourceFor(gvr)
+	if err != nil {
+		return gvr, true
+	}
+	gvk, err := k.deferredDiscoveryRESTMapper.KindFor(resolved)
+	if err != nil {
+		return resolved, true
+	}
+	mapping, err := k.deferredDiscoveryRESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return resolved, true
+	}
+	return resolved, mapping.Scope.Name() == meta.RESTScopeNameNamespace
+}
@@ -16,6 +16,22 @@ func (k *Kubernetes) IsInCluster() bool {
 	return err == nil && cfg != nil
 }
 
+// CurrentContext returns the name of the kubeconfig context in use and of its cluster.
+// Both are empty in cluster or when the kubeconfig can't be read.
+func (k *Kubernetes) CurrentContext() (context, cluster string) {
+	if k.IsInCluster() {
+		return "", ""
+	}
+	rawConfig, err := k.clientCmdConfig.RawConfig()
+	if err != nil {
+		return "", ""
+	}
+	if kubeContext, ok := rawConfig.Contexts[rawConfig.CurrentContext]; ok {
+		cluster = kubeContext.Cluster
+	}
+	return rawConfig.CurrentContext, cluster
+}
+
 // InClusterConfig is a variable that holds the function to get the in-cluster config
 // Exposed for testing
 var InClusterConfig = func() (*rest.Config, error) {
@@ -28,7 +44,12 @@ var InClusterConfig = func() (*rest.Config, error) {
 	return inClusterConfig, err
 }
 
-func (k *Kubernetes) ConfigurationView(minify bool) (string, error) {
+// redactedValue is the marker that replaces credentials in configuration views.
+const redactedValue = "REDACTED"
+
+// ConfigurationView returns the kubeconfig in use as YAML. Unless redact is false,
+// tokens, passwords, client keys and auth plugin settings are replaced with REDACTED.
+func (k *Kubernetes) ConfigurationView(minify, redact bool) (string, error) {
 	var cfg clientcmdapi.Config
 	var err error
 
@@ -37,6 +58,14 @@ func (k *Kubernetes) ConfigurationView(minify bool) (string, error) {
 	} else if cfg, err = k.clientCmdConfig.RawConfig(); err != nil {
 		return "", err
 	}
+	// RawConfig shares its clusters, contexts and auth infos with the cached client config
+	cfg = *cfg.DeepCopy()
+
+	if redact {
+		if err = redactConfig(&cfg); err != nil {
+			return "", err
+		}
+	}
 
 	if minify {
 		if err = clientcmdapi.MinifyConfig(&cfg); err != nil {
@@ -73,20 +102,49 @@ func (k *Kubernetes) buildInClusterConfig() clientcmdapi.Config {
 	return cfg
 }
 
+// redactConfig replaces every credential held by the auth infos of cfg with REDACTED.
+func redactConfig(cfg *clientcmdapi.Config) error {
+	if err := clientcmdapi.RedactSecrets(cfg); err != nil {
+		return err
+	}
+
+	// RedactSecrets leaves auth provider settings and exec environments alone,
+	// but they commonly carry refresh tokens and client secrets
+	for _, authInfo := range cfg.AuthInfos {
+		if authInfo.AuthProvider != nil {
+			for key := range authInfo.AuthProvider.Config {
+				authInfo.AuthProvider.Config[key] = redactedValue
+			}
+		}
+		if authInfo.Exec != nil {
+			for i := range authInfo.Exec.Env {
+				authInfo.Exec.Env[i].Value = redactedValue
+			}
+		}
+	}
+	return nil
+}
+
+// marshal strips the managed fields and masks the Secret values of the objects in v
+// before serializing it as YAML.
 func marshal(v any) (string, error) {
+	sanitize := func(obj *unstructured.Unstructured) {
+		obj.SetManagedFields(nil)
+		maskSecret(obj)
+	}
 	switch t := v.(type) {
 	case []unstructured.Unstructured:
 		for i := range t {
-			t[i].SetManagedFields(nil)
+			sanitize(&t[i])
 		}
 	case []*unstructured.Unstructured:
 		for i := range t {
-			t[i].SetManagedFields(nil)
+			sanitize(t[i])
 		}
 	case unstructured.Unstructured:
-		t.SetManagedFields(nil)
+		sanitize(&t)
 	case *unstructured.Unstructured:
-		t.SetManagedFields(nil)
+		sanitize(t)
 	}
 	ret, err := yaml.Marshal(v)
 	if err != nil {
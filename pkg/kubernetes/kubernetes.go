@@ -1,6 +1,7 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/fsnotify/fsnotify"
@@ -29,11 +30,40 @@ type Kubernetes struct {
 	dynamicClient               *dynamic.DynamicClient
 	scheme                      *runtime.Scheme
 	parameterCodec              runtime.ParameterCodec
+	namespaceGuard              *namespaceGuard
+	// qps and burst override the rate limits of the client-go configuration when set.
+	qps   float32
+	burst int
 }
 
-func NewKubernetes(kubeconfig string) (*Kubernetes, error) {
+// Option configures a Kubernetes client.
+type Option func(*Kubernetes)
+
+// WithNamespaceGuard restricts every request to the namespaces matching one of the
+// allowed glob patterns, all if empty, and none of the denied glob patterns.
+func WithNamespaceGuard(allowed, denied []string) Option {
+	return func(k *Kubernetes) {
+		k.namespaceGuard = &namespaceGuard{allowed: allowed, denied: denied}
+	}
+}
+
+// WithRateLimits bounds the requests sent to the API server to qps per second with
+// bursts of up to burst requests. The client-go defaults are kept for zero values.
+func WithRateLimits(qps float32, burst int) Option {
+	return func(k *Kubernetes) {
+		k.qps = qps
+		k.burst = burst
+	}
+}
+
+func NewKubernetes(kubeconfig string, opts ...Option) (*Kubernetes, error) {
 	k := &Kubernetes{
-		Kubeconfig: kubeconfig,
+		Kubeconfig:     kubeconfig,
+		namespaceGuard: &namespaceGuard{},
+	}
+
+	for _, o := range opts {
+		o(k)
 	}
 
 	if err := k.resolveKubernentesConfigurations(); err != nil {
@@ -51,6 +81,37 @@ func NewKubernetes(kubeconfig string) (*Kubernetes, error) {
 	return k, nil
 }
 
+// Derived returns a Kubernetes client that shares the resolved configuration of k
+// but authenticates against the API server with the provided bearer token
+// instead of the credentials found in the kubeconfig.
+func (k *Kubernetes) Derived(token string) (*Kubernetes, error) {
+	if token == "" {
+		return nil, fmt.Errorf("bearer token cannot be empty")
+	}
+
+	derivedCfg := rest.AnonymousClientConfig(k.cfg)
+	derivedCfg.BearerToken = token
+
+	derived := &Kubernetes{
+		Kubeconfig:      k.Kubeconfig,
+		cfg:             derivedCfg,
+		clientCmdConfig: k.clientCmdConfig,
+		namespaceGuard:  k.namespaceGuard,
+		qps:             k.qps,
+		burst:           k.burst,
+	}
+
+	if err := derived.initializeClients(); err != nil {
+		return nil, err
+	}
+
+	if err := derived.initializeScheme(); err != nil {
+		return nil, err
+	}
+
+	return derived, nil
+}
+
 func (k *Kubernetes) resolveKubernentesConfigurations() error {
 	// Initialize config from kubeconfig path if provided
 	config, err := k.loadKubeConfig()
@@ -63,6 +124,7 @@ func (k *Kubernetes) resolveKubernentesConfigurations() error {
 		inClusterCfg, err := InClusterConfig()
 		if err == nil && inClusterCfg != nil {
 			k.cfg = inClusterCfg
+			k.applyRateLimits()
 			return nil
 		}
 	}
@@ -74,10 +136,23 @@ func (k *Kubernetes) resolveKubernentesConfigurations() error {
 	if k.cfg != nil && k.cfg.UserAgent == "" {
 		k.cfg.UserAgent = rest.DefaultKubernetesUserAgent()
 	}
+	k.applyRateLimits()
 
 	return nil
 }
 
+func (k *Kubernetes) applyRateLimits() {
+	if k.cfg == nil {
+		return
+	}
+	if k.qps > 0 {
+		k.cfg.QPS = k.qps
+	}
+	if k.burst > 0 {
+		k.cfg.Burst = k.burst
+	}
+}
+
 // loadKubeConfig handles loading the kubernetes configuration from the kubeconfig file
 func (k *Kubernetes) loadKubeConfig() (*rest.Config, error) {
 	pathOptions := clientcmd.NewDefaultPathOptions()
@@ -169,6 +244,23 @@ func (k *Kubernetes) Close() {
 	}
 }
 
+// namespaceKey is how we find the default namespace override in a context.Context.
+type namespaceKey struct{}
+
+// WithNamespace returns a copy of ctx in which namespace replaces the namespace of the
+// kubeconfig context as the default for namespaced resources.
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// DefaultNamespace returns the namespace used when a namespaced request doesn't specify one.
+func (k *Kubernetes) DefaultNamespace(ctx context.Context) string {
+	if namespace, ok := ctx.Value(namespaceKey{}).(string); ok && namespace != "" {
+		return namespace
+	}
+	return k.configuredNamespace()
+}
+
 func (k *Kubernetes) configuredNamespace() (namespace string) {
 	namespace, _, err := k.clientCmdConfig.Namespace()
 	if err != nil {
@@ -2,14 +2,57 @@ package kubernetes
 
 import (
 	"context"
+	"fmt"
+	"path"
+	"slices"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// namespaceGuard decides which namespaces requests are allowed to reach.
+type namespaceGuard struct {
+	allowed []string
+	denied  []string
+}
+
+func (g *namespaceGuard) allows(namespace string) bool {
+	matches := func(patterns []string) bool {
+		return slices.ContainsFunc(patterns, func(pattern string) bool {
+			matched, _ := path.Match(pattern, namespace)
+			return matched
+		})
+	}
+	if len(g.allowed) > 0 && !matches(g.allowed) {
+		return false
+	}
+	return !matches(g.denied)
+}
+
+// CheckNamespace returns an error if the namespace policy forbids requests to namespace.
+func (k *Kubernetes) CheckNamespace(namespace string) error {
+	if !k.namespaceGuard.allows(namespace) {
+		return fmt.Errorf("namespace %q is not allowed by the namespace policy", namespace)
+	}
+	return nil
+}
+
 func (k *Kubernetes) NamespacesList(ctx context.Context) (string, error) {
 	return k.ResourcesList(
 		ctx,
-		&schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Namesapce"},
+		&schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Namespace"},
 		"",
 	)
 }
+
+// NamespaceLabels returns the labels of the namespace with the given name.
+func (k *Kubernetes) NamespaceLabels(ctx context.Context, name string) (map[string]string, error) {
+	if err := k.CheckNamespace(name); err != nil {
+		return nil, err
+	}
+	namespace, err := k.clientSet.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace: %w", err)
+	}
+	return namespace.GetLabels(), nil
+}
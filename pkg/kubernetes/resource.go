@@ -3,6 +3,7 @@ package kubernetes
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	authv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,15 +27,40 @@ func (k *Kubernetes) ResourcesList(ctx context.Context, gvk *schema.GroupVersion
 }
 
 func (k *Kubernetes) resourcesList(ctx context.Context, gvk *schema.GroupVersionKind, namespace string) (*unstructured.UnstructuredList, error) {
-	gvr, err := k.GetGroupVersionResource(gvk)
+	gvr, err := discover(ctx, func() (*schema.GroupVersionResource, error) {
+		return k.GetGroupVersionResource(gvk)
+	})
 	if err != nil {
 		return nil, err
 	}
-	isNamespaced, _ := k.checkResourceNamespaced(gvk)
+	isNamespaced, _ := discover(ctx, func() (bool, error) {
+		return k.checkResourceNamespaced(gvk)
+	})
 	if isNamespaced && k.checkResourceAccess(ctx, gvr, namespace, "list") && namespace == "" {
-		namespace = k.configuredNamespace()
+		namespace = k.DefaultNamespace(ctx)
 	}
-	return k.dynamicClient.Resource(*gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if isNamespaced && namespace != "" {
+		if err := k.CheckNamespace(namespace); err != nil {
+			return nil, err
+		}
+	}
+	list, err := k.dynamicClient.Resource(*gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Cluster wide lists only keep what lives in, or is, a permitted namespace
+	switch {
+	case isNamespaced && namespace == "":
+		list.Items = slices.DeleteFunc(list.Items, func(item unstructured.Unstructured) bool {
+			return !k.namespaceGuard.allows(item.GetNamespace())
+		})
+	case gvr.Group == "" && gvr.Resource == "namespaces":
+		list.Items = slices.DeleteFunc(list.Items, func(item unstructured.Unstructured) bool {
+			return !k.namespaceGuard.allows(item.GetName())
+		})
+	}
+	return list, nil
 }
 
 // GetGroupVersionResource returns the GroupVersionResource for a given GroupVersionKind
@@ -78,20 +104,36 @@ func (k *Kubernetes) checkResourceNamespaced(gvk *schema.GroupVersionKind) (bool
 // CheckResourceAccess verifies if the current user has permission to perform
 // the specified verb on a resource in the given namespace
 func (k *Kubernetes) checkResourceAccess(ctx context.Context, gvr *schema.GroupVersionResource, namespace, verb string) bool {
-	review := &authv1.SelfSubjectAccessReview{
-		Spec: authv1.SelfSubjectAccessReviewSpec{
-			ResourceAttributes: &authv1.ResourceAttributes{
-				Namespace: namespace,
-				Verb:      verb,
-				Group:     gvr.Group,
-				Version:   gvr.Version,
-				Resource:  gvr.Resource,
-			},
-		},
-	}
-	response, err := k.clientSet.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	status, err := k.accessReview(ctx, &authv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      verb,
+		Group:     gvr.Group,
+		Version:   gvr.Version,
+		Resource:  gvr.Resource,
+	})
 	if err != nil {
 		return false
 	}
-	return response.Status.Allowed
+	return status.Allowed
+}
+
+// discover runs a discovery call, which client-go cannot cancel, until it returns
+// or ctx is done. In the latter case the call completes in the background.
+func discover[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value: value, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, context.Cause(ctx)
+	}
 }
@@ -0,0 +1,60 @@
+package kubernetes
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// lastAppliedConfigAnnotation is where kubectl apply keeps a copy of the applied object.
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// MaskSecret masks the values of obj, as maskSecret does, if it is a Secret.
+func MaskSecret(obj map[string]any) {
+	maskSecret(&unstructured.Unstructured{Object: obj})
+}
+
+func isSecret(obj *unstructured.Unstructured) bool {
+	return obj.GetKind() == "Secret" && obj.GroupVersionKind().Group == ""
+}
+
+// maskSecret replaces the values of data and stringData of a Secret, including the
+// copy embedded in the last applied configuration annotation, with REDACTED.
+// Keys are kept so that the content of the Secret can still be discussed.
+func maskSecret(obj *unstructured.Unstructured) {
+	if !isSecret(obj) {
+		return
+	}
+	maskSecretData(obj.Object)
+
+	annotations := obj.GetAnnotations()
+	lastApplied, ok := annotations[lastAppliedConfigAnnotation]
+	if !ok {
+		return
+	}
+	var applied map[string]any
+	if err := json.Unmarshal([]byte(lastApplied), &applied); err != nil {
+		annotations[lastAppliedConfigAnnotation] = redactedValue
+	} else {
+		maskSecretData(applied)
+		masked, err := json.Marshal(applied)
+		if err != nil {
+			annotations[lastAppliedConfigAnnotation] = redactedValue
+		} else {
+			annotations[lastAppliedConfigAnnotation] = string(masked)
+		}
+	}
+	obj.SetAnnotations(annotations)
+}
+
+func maskSecretData(secret map[string]any) {
+	for _, field := range []string{"data", "stringData"} {
+		values, ok := secret[field].(map[string]any)
+		if !ok {
+			continue
+		}
+		for key := range values {
+			values[key] = redactedValue
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package kubernetes
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestMarshalMasksSecrets(t *testing.T) {
+	object := func(apiVersion, kind string, annotations map[string]any) unstructured.Unstructured {
+		metadata := map[string]any{"name": "credentials"}
+		if annotations != nil {
+			metadata["annotations"] = annotations
+		}
+		return unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   metadata,
+			"data":       map[string]any{"password": "c2VjcmV0"},
+			"stringData": map[string]any{"token": "plain-secret"},
+		}}
+	}
+	tests := []struct {
+		name       string
+		obj        unstructured.Unstructured
+		wantMasked bool
+	}{
+		{
+			name:       "secret",
+			obj:        object("v1", "Secret", nil),
+			wantMasked: true,
+		},
+		{
+			name: "secret with last applied configuration",
+			obj: object("v1", "Secret", map[string]any{
+				lastAppliedConfigAnnotation: `{"apiVersion":"v1","kind":"Secret","data":{"password":"c2VjcmV0"},"stringData":{"token":"plain-secret"}}`,
+			}),
+			wantMasked: true,
+		},
+		{
+			name: "secret with invalid last applied configuration",
+			obj: object("v1", "Secret", map[string]any{
+				lastAppliedConfigAnnotation: `{"data":{"password":"c2VjcmV0"`,
+			}),
+			wantMasked: true,
+		},
+		{
+			name: "config map",
+			obj:  object("v1", "ConfigMap", nil),
+		},
+		{
+			name: "secret kind of another group",
+			obj:  object("example.com/v1", "Secret", nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := marshal([]unstructured.Unstructured{tt.obj})
+			if err != nil {
+				t.Fatalf("marshal() error = %v", err)
+			}
+			leaked := strings.Contains(got, "c2VjcmV0") || strings.Contains(got, "plain-secret")
+			if leaked == tt.wantMasked {
+				t.Errorf("marshal() = %s, want values masked: %v", got, tt.wantMasked)
+			}
+			if !strings.Contains(got, "password") || !strings.Contains(got, "token") {
+				t.Errorf("marshal() = %s, want the keys kept", got)
+			}
+		})
+	}
+}
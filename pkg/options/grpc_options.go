@@ -8,8 +8,7 @@ import (
 
 var _ IOptions = (*GRPCOptions)(nil)
 
-// GRPCOptions are for creating an unauthenticated, unauthorized, insecure port.
-// No one should be using these anymore.
+// GRPCOptions contains the listener options of a gRPC server.
 type GRPCOptions struct {
 	// Network with server network.
 	Network string `json:"network" mapstructure:"network"`
@@ -17,12 +16,11 @@ type GRPCOptions struct {
 	// Address with server address.
 	Addr string `json:"addr" mapstructure:"addr"`
 
-	// Timeout with server timeout. Used by grpc client side.
+	// Timeout with server timeout. Bounds the connection handshake on the server side.
 	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
 }
 
-// NewGRPCOptions is for creating an unauthenticated, unauthorized, insecure port.
-// No one should be using these anymore.
+// NewGRPCOptions creates a GRPCOptions object with default parameters.
 func NewGRPCOptions() *GRPCOptions {
 	return &GRPCOptions{
 		Network: "tcp",
@@ -1,6 +1,13 @@
 package options
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -10,22 +17,33 @@ var _ IOptions = (*HTTPOptions)(nil)
 
 // HTTPOptions contains configuration items related to HTTP server startup.
 type HTTPOptions struct {
-	// Network with server network.
+	// Network with server network, tcp, tcp4, tcp6 or unix.
 	Network string `json:"network" mapstructure:"network"`
 
-	// Address with server address.
+	// Address with server address, the socket path when Network is unix.
 	Addr string `json:"addr" mapstructure:"addr"`
 
 	// Timeout with server timeout. Used by http client side.
 	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+
+	// ReadTimeout, WriteTimeout and IdleTimeout bound the connections accepted
+	// by the server, unbounded if 0.
+	ReadTimeout  time.Duration `json:"read-timeout" mapstructure:"read-timeout"`
+	WriteTimeout time.Duration `json:"write-timeout" mapstructure:"write-timeout"`
+	IdleTimeout  time.Duration `json:"idle-timeout" mapstructure:"idle-timeout"`
+
+	// SocketMode is the octal file mode of the socket when Network is unix.
+	SocketMode string `json:"socket-mode" mapstructure:"socket-mode"`
 }
 
 // NewHTTPOptions creates a HTTPOptions object with default parameters.
 func NewHTTPOptions() *HTTPOptions {
 	return &HTTPOptions{
-		Network: "tcp",
-		Addr:    "0.0.0.0:38443",
-		Timeout: 30 * time.Second,
+		Network:     "tcp",
+		Addr:        "0.0.0.0:38443",
+		Timeout:     30 * time.Second,
+		IdleTimeout: 2 * time.Minute,
+		SocketMode:  "0600",
 	}
 }
 
@@ -34,18 +52,88 @@ func (o *HTTPOptions) Validate() []error {
 		return nil
 	}
 
-	errors := []error{}
-	if err := ValidateAddress(o.Addr); err != nil {
-		errors = append(errors, err)
+	errs := []error{}
+	switch o.Network {
+	case "unix":
+		if o.Addr == "" {
+			errs = append(errs, fmt.Errorf("the socket path is required with the unix network"))
+		}
+		if _, err := o.socketMode(); err != nil {
+			errs = append(errs, err)
+		}
+	case "tcp", "tcp4", "tcp6":
+		if err := ValidateAddress(o.Addr); err != nil {
+			errs = append(errs, err)
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported network %q, must be tcp, tcp4, tcp6 or unix", o.Network))
+	}
+	if o.ReadTimeout < 0 || o.WriteTimeout < 0 || o.IdleTimeout < 0 {
+		errs = append(errs, fmt.Errorf("http server timeouts cannot be negative"))
 	}
 
-	return errors
+	return errs
 }
 
 // AddFlags adds flags related to HTTPS server for a specific APIServer to the
 // specified FlagSet.
 func (o *HTTPOptions) AddFlags(fs *pflag.FlagSet, prefixes ...string) {
-	fs.StringVar(&o.Network, "http.network", o.Network, "Specify the network for the HTTP server.")
-	fs.StringVar(&o.Addr, "http.addr", o.Addr, "Specify the HTTP server bind address and port.")
-	fs.DurationVar(&o.Timeout, "http.timeout", o.Timeout, "Timeout for server connections.")
+	fs.StringVar(&o.Network, join(prefixes...)+"http.network", o.Network, "Specify the network for the HTTP server, tcp, tcp4, tcp6 or unix.")
+	fs.StringVar(&o.Addr, join(prefixes...)+"http.addr", o.Addr, "Specify the HTTP server bind address and port, "+
+		"or the socket path with the unix network.")
+	fs.DurationVar(&o.Timeout, join(prefixes...)+"http.timeout", o.Timeout, "Timeout for server connections.")
+	fs.DurationVar(&o.ReadTimeout, join(prefixes...)+"http.read-timeout", o.ReadTimeout, "Maximum duration for reading an entire request, "+
+		"unbounded if 0.")
+	fs.DurationVar(&o.WriteTimeout, join(prefixes...)+"http.write-timeout", o.WriteTimeout, "Maximum duration for writing a response, "+
+		"unbounded if 0.")
+	fs.DurationVar(&o.IdleTimeout, join(prefixes...)+"http.idle-timeout", o.IdleTimeout, "Maximum duration a keep-alive connection "+
+		"waits for the next request, unbounded if 0.")
+	fs.StringVar(&o.SocketMode, join(prefixes...)+"http.socket-mode", o.SocketMode, "Octal file mode of the socket with the unix network.")
+}
+
+// ApplyTo sets the timeouts of server.
+func (o *HTTPOptions) ApplyTo(server *http.Server) {
+	server.ReadTimeout = o.ReadTimeout
+	server.WriteTimeout = o.WriteTimeout
+	server.IdleTimeout = o.IdleTimeout
+}
+
+// Listen announces on the network and address of the options. Stale unix
+// sockets are removed first, and new ones get the socket mode.
+func (o *HTTPOptions) Listen() (net.Listener, error) {
+	if o.Network != "unix" {
+		return net.Listen(o.Network, o.Addr)
+	}
+
+	mode, err := o.socketMode()
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(o.Addr); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", o.Addr)
+		}
+		if err := os.Remove(o.Addr); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", o.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(o.Addr, mode); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+func (o *HTTPOptions) socketMode() (fs.FileMode, error) {
+	mode, err := strconv.ParseUint(o.SocketMode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid socket mode %q, must be octal permissions such as 0600", o.SocketMode)
+	}
+	return fs.FileMode(mode), nil
 }
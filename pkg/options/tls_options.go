@@ -6,7 +6,10 @@ import (
 	"encoding/pem"
 	"fmt"
 	"os"
+	"sync"
+	"time"
 
+	"github.com/fleezesd/mcp-kubernetes/pkg/log"
 	"github.com/spf13/pflag"
 )
 
@@ -19,11 +22,23 @@ type TLSOptions struct {
 	CaCert             string `json:"ca-cert" mapstructure:"ca-cert"`
 	Cert               string `json:"cert" mapstructure:"cert"`
 	Key                string `json:"key" mapstructure:"key"`
+	// MinVersion is the minimum TLS version accepted, 1.2 or 1.3.
+	MinVersion string `json:"min-version" mapstructure:"min-version"`
+	// ClientCA is the CA verifying client certificates when serving, which enables mutual TLS.
+	ClientCA string `json:"client-ca" mapstructure:"client-ca"`
+}
+
+// tlsVersions maps the accepted values of MinVersion to their TLS version.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
 }
 
 // NewTLSOptions create a `zero` value instance.
 func NewTLSOptions() *TLSOptions {
-	return &TLSOptions{}
+	return &TLSOptions{
+		MinVersion: "1.2",
+	}
 }
 
 // Validate verifies flags passed to TLSOptions.
@@ -38,6 +53,10 @@ func (o *TLSOptions) Validate() []error {
 		errs = append(errs, fmt.Errorf("only one of cert and key configuration option is setted, you should set both to enable tls"))
 	}
 
+	if _, ok := tlsVersions[o.MinVersion]; o.MinVersion != "" && !ok {
+		errs = append(errs, fmt.Errorf("unsupported tls min version %q, must be 1.2 or 1.3", o.MinVersion))
+	}
+
 	return errs
 }
 
@@ -49,6 +68,9 @@ func (o *TLSOptions) AddFlags(fs *pflag.FlagSet, prefixes ...string) {
 	fs.StringVar(&o.CaCert, join(prefixes...)+"tls.ca-cert", o.CaCert, "Path to ca cert for connecting to the server.")
 	fs.StringVar(&o.Cert, join(prefixes...)+"tls.cert", o.Cert, "Path to cert file for connecting to the server.")
 	fs.StringVar(&o.Key, join(prefixes...)+"tls.key", o.Key, "Path to key file for connecting to the server.")
+	fs.StringVar(&o.MinVersion, join(prefixes...)+"tls.min-version", o.MinVersion, "Minimum TLS version, 1.2 or 1.3.")
+	fs.StringVar(&o.ClientCA, join(prefixes...)+"tls.client-ca", o.ClientCA, "Path to ca cert verifying client certificates "+
+		"when serving, which enables mutual tls.")
 }
 
 func (o *TLSOptions) MustTLSConfig() *tls.Config {
@@ -66,6 +88,7 @@ func (o *TLSOptions) TLSConfig() (*tls.Config, error) {
 
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: o.InsecureSkipVerify,
+		MinVersion:         tlsVersions[o.MinVersion],
 	}
 
 	// load cert & key
@@ -81,36 +104,152 @@ func (o *TLSOptions) TLSConfig() (*tls.Config, error) {
 
 	// Load CA certificate from file if specified
 	if o.CaCert != "" {
-		// Read the CA certificate file
-		data, err := os.ReadFile(o.CaCert)
+		capool, err := loadCertPool(o.CaCert)
 		if err != nil {
 			return nil, err
 		}
+		// Set the root CA pool in TLS config
+		tlsConfig.RootCAs = capool
+	}
 
-		// Create a new certificate pool for CA certs
-		capool := x509.NewCertPool()
-
-		// Iterate through all PEM blocks in the file
-		for {
-			// Decode the next PEM block
-			var block *pem.Block
-			block, _ = pem.Decode(data)
-			// Break if no more PEM blocks found and parse cert
-			if block == nil {
-				break
-			}
+	return tlsConfig, nil
+}
+
+// ServerTLSConfig returns the TLS configuration of a server presenting Cert and Key.
+// The certificate, and ClientCA if set, are reloaded whenever their files change.
+// When ClientCA is set, clients must present a certificate it signed.
+func (o *TLSOptions) ServerTLSConfig() (*tls.Config, error) {
+	if !o.UseTLS {
+		return nil, nil
+	}
+	if o.Cert == "" || o.Key == "" {
+		return nil, fmt.Errorf("serving tls requires both cert and key")
+	}
 
-			// Parse the certificate from the PEM block
-			cacert, err := x509.ParseCertificate(block.Bytes)
-			if err != nil {
-				return nil, err
+	r := &certReloader{certFile: o.Cert, keyFile: o.Key, clientCAFile: o.ClientCA}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	base := &tls.Config{
+		MinVersion: tlsVersions[o.MinVersion],
+	}
+	if o.ClientCA != "" {
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return &tls.Config{
+		MinVersion: base.MinVersion,
+		// Only the config returned for the client is used for the handshake, but users
+		// of the server config tell from it whether client certificates are required
+		ClientAuth: base.ClientAuth,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			if err := r.reloadIfDue(time.Now()); err != nil {
+				// Keep serving the last good certificates until the files are fixed
+				log.Errorw(err, "Failed to reload tls certificates")
 			}
-			// Add the parsed certificate to the pool
-			capool.AddCert(cacert)
+			cert, clientCAs := r.current()
+			config := base.Clone()
+			config.Certificates = []tls.Certificate{*cert}
+			config.ClientCAs = clientCAs
+			return config, nil
+		},
+	}, nil
+}
+
+// reloadInterval is how often handshakes check whether the certificate files changed.
+const reloadInterval = time.Second
+
+// certReloader loads a certificate and a client CA, and loads them again when
+// the modification time of their files changes.
+type certReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	modTimes  [3]time.Time
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+func (r *certReloader) reload() error {
+	var modTimes [3]time.Time
+	for i, file := range []string{r.certFile, r.keyFile, r.clientCAFile} {
+		if file == "" {
+			continue
 		}
-		// Set the root CA pool in TLS config
-		tlsConfig.RootCAs = capool
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[i] = info.ModTime()
 	}
 
-	return tlsConfig, nil
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert != nil && modTimes == r.modTimes {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load tls certificates: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if r.clientCAFile != "" {
+		if clientCAs, err = loadCertPool(r.clientCAFile); err != nil {
+			return fmt.Errorf("failed to load client ca: %w", err)
+		}
+	}
+	r.cert, r.clientCAs, r.modTimes = &cert, clientCAs, modTimes
+	return nil
+}
+
+// reloadIfDue reloads the files unless they were checked less than reloadInterval
+// before now, so that every handshake doesn't stat them, nor log the same error.
+func (r *certReloader) reloadIfDue(now time.Time) error {
+	r.mu.Lock()
+	due := now.Sub(r.checkedAt) >= reloadInterval
+	if due {
+		r.checkedAt = now
+	}
+	r.mu.Unlock()
+	if !due {
+		return nil
+	}
+	return r.reload()
+}
+
+func (r *certReloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cert, r.clientCAs
+}
+
+// loadCertPool reads every PEM encoded certificate of file into a pool.
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, count := x509.NewCertPool(), 0
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		pool.AddCert(cert)
+		count++
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("no certificate found in %s", file)
+	}
+	return pool, nil
 }